@@ -28,6 +28,8 @@ func main() {
 
 	var (
 		configPath = flag.String("c", "config.yaml", "Path to configuration file")
+		dryRun     = flag.Bool("dry-run", false, "Log changes of resources instead of writing them")
+		serverSide = flag.Bool("server-dry-run", false, "Send changes to the API server with dry-run option (implies -dry-run)")
 		version    = flag.Bool("version", false, "Display version information and exit")
 	)
 
@@ -44,6 +46,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *dryRun || *serverSide {
+		c.DryRun = &config.DryRunConfig{
+			Enabled:    true,
+			ServerSide: *serverSide,
+		}
+	}
+
 	kc, err := kconfig.GetConfig()
 	if err != nil {
 		log.Error(err, "could not load kubernetes configuration")
@@ -31,17 +31,64 @@ kind: ServiceAccount
 metadata:
   name: {{ .Name }}
   namespace: {{ .Namespace }}
+{{- if .Config.Namespaces }}
+{{- range $namespace := .Config.Namespaces }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ $.Name }}
+  namespace: {{ $namespace }}
+{{ template "rules" $ }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{ $.Name }}
+  namespace: {{ $namespace }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: {{ $.Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ $.Name }}
+  namespace: {{ $.Namespace }}
+{{- end }}
+{{- else }}
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
   name: {{ .Name }}
+{{ template "rules" . }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: {{ .Name }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: {{ .Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+{{- end }}
+{{- if .Config.LeaderElection }}
+{{- if .Config.LeaderElection.Enabled }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{ .Name }}-leader-election
+  namespace: {{ or .Config.LeaderElection.Namespace .Namespace }}
 rules:
-{{ range .Config.Resources -}}
 - apiGroups:
-  - {{ .Group }}
+  - ""
   resources:
-  - {{ .Kind | toLower }}
+  - configmaps
   verbs:
   - get
   - list
@@ -50,11 +97,10 @@ rules:
   - update
   - patch
   - delete
-{{ range .Dependents -}}
 - apiGroups:
-  - {{ .Group }}
+  - coordination.k8s.io
   resources:
-  - {{ .Kind | toLower }}
+  - leases
   verbs:
   - get
   - list
@@ -63,18 +109,6 @@ rules:
   - update
   - patch
   - delete
-{{ end -}}
-{{ range .References -}}
-- apiGroups:
-  - {{ .Group }}
-  resources:
-  - {{ .Kind | toLower }}
-  verbs:
-  - get
-  - list
-  - watch
-{{ end -}}
-{{ end -}}
 - apiGroups:
   - ""
   resources:
@@ -84,17 +118,20 @@ rules:
   - patch
 ---
 apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
+kind: RoleBinding
 metadata:
-  name: {{ .Name }}
+  name: {{ .Name }}-leader-election
+  namespace: {{ or .Config.LeaderElection.Namespace .Namespace }}
 roleRef:
   apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: {{ .Name }}
+  kind: Role
+  name: {{ .Name }}-leader-election
 subjects:
 - kind: ServiceAccount
   name: {{ .Name }}
   namespace: {{ .Namespace }}
+{{- end }}
+{{- end }}
 ---
 apiVersion: apps/v1
 kind: Deployment
@@ -120,7 +157,18 @@ spec:
           mountPath: /etc/tls
         ports:
         - containerPort: 443
-        - containerPort: 8080
+        - containerPort: {{ if .Config.Metrics }}{{ .Config.Metrics.Port }}{{ else }}8080{{ end }}
+{{- if .Config.Health }}
+        - containerPort: {{ .Config.Health.Port }}
+        livenessProbe:
+          httpGet:
+            path: {{ or .Config.Health.LivenessPath "/healthz" }}
+            port: {{ .Config.Health.Port }}
+        readinessProbe:
+          httpGet:
+            path: {{ or .Config.Health.ReadinessPath "/readyz" }}
+            port: {{ .Config.Health.Port }}
+{{- end }}
       volumes:
       - name: certificates
         secret:
@@ -140,4 +188,73 @@ spec:
   - protocol: TCP
     port: 443
     targetPort: 443
+
+{{- define "rules" -}}
+rules:
+{{ range .Config.Resources -}}
+- apiGroups:
+  - {{ .Group }}
+  resources:
+  - {{ .Kind | toLower }}
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+{{ if .StatusSubresource -}}
+- apiGroups:
+  - {{ .Group }}
+  resources:
+  - {{ .Kind | toLower }}/status
+  verbs:
+  - get
+  - update
+  - patch
+{{ end -}}
+{{ range .Dependents -}}
+- apiGroups:
+  - {{ .Group }}
+  resources:
+  - {{ .Kind | toLower }}
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+{{ end -}}
+{{ range .References -}}
+- apiGroups:
+  - {{ .Group }}
+  resources:
+  - {{ .Kind | toLower }}
+  verbs:
+  - get
+  - list
+  - watch
+{{ end -}}
+{{ range .Watches -}}
+- apiGroups:
+  - {{ .Group }}
+  resources:
+  - {{ .Kind | toLower }}
+  verbs:
+  - get
+  - list
+  - watch
+{{ end -}}
+{{ end -}}
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+{{- end }}
 `
@@ -50,5 +50,9 @@ spec:
     kind: {{ .Kind }}
     plural: {{ .Kind | toLower }}
     singular: {{ .Kind | toLower }}
-  scope: Namespaced
+  scope: {{ or .Scope "Namespaced" }}
+{{- if .StatusSubresource }}
+  subresources:
+    status: {}
+{{- end }}
 `
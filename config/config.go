@@ -4,18 +4,27 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
 )
 
 type Config struct {
-	Name      string            `json:"name,omitempty"`
-	Resources []*ResourceConfig `json:"resources"`
-	Webhook   *ServerConfig     `json:"webhook,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Namespaces []string          `json:"namespaces,omitempty"`
+	Resources  []*ResourceConfig `json:"resources"`
+	Webhook    *ServerConfig     `json:"webhook,omitempty"`
+	Metrics    *MetricsConfig    `json:"metrics,omitempty"`
+	Health     *HealthConfig     `json:"health,omitempty"`
+
+	LeaderElection *LeaderElectionConfig `json:"leaderElection,omitempty"`
+	DryRun         *DryRunConfig         `json:"dryRun,omitempty"`
 }
 
 func LoadFile(p string) (*Config, error) {
@@ -34,6 +43,19 @@ func LoadFile(p string) (*Config, error) {
 }
 
 func (c *Config) Validate() error {
+	namespaces := map[string]struct{}{}
+	for i, ns := range c.Namespaces {
+		if ns == "" {
+			return fmt.Errorf("namespaces[%d] is empty", i)
+		}
+
+		_, ok := namespaces[ns]
+		if ok {
+			return fmt.Errorf("namespaces[%d] is duplicated", i)
+		}
+		namespaces[ns] = struct{}{}
+	}
+
 	if len(c.Resources) == 0 {
 		return errors.New("at least one resource must be specified")
 	}
@@ -43,6 +65,13 @@ func (c *Config) Validate() error {
 		if err != nil {
 			return fmt.Errorf("resources[%d]: %v", i, err)
 		}
+
+		if len(c.Namespaces) > 0 {
+			err := r.validateNamespaces(namespaces)
+			if err != nil {
+				return fmt.Errorf("resources[%d]: %v", i, err)
+			}
+		}
 	}
 
 	if c.Webhook != nil {
@@ -52,18 +81,58 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Metrics != nil {
+		err := c.Metrics.Validate()
+		if err != nil {
+			return fmt.Errorf("metrics: %v", err)
+		}
+	}
+
+	if c.Health != nil {
+		err := c.Health.Validate()
+		if err != nil {
+			return fmt.Errorf("health: %v", err)
+		}
+	}
+
+	if c.LeaderElection != nil {
+		err := c.LeaderElection.Validate()
+		if err != nil {
+			return fmt.Errorf("leaderElection: %v", err)
+		}
+	}
+
+	if c.DryRun != nil {
+		err := c.DryRun.Validate()
+		if err != nil {
+			return fmt.Errorf("dryRun: %v", err)
+		}
+	}
+
 	return nil
 }
 
+const (
+	// ScopeNamespaced indicates that the resource is namespaced.
+	ScopeNamespaced = "Namespaced"
+	// ScopeCluster indicates that the resource is cluster-scoped.
+	ScopeCluster = "Cluster"
+)
+
 type ResourceConfig struct {
 	schema.GroupVersionKind
+	Scope string `json:"scope,omitempty"`
 
 	Dependents []DependentConfig `json:"dependents,omitempty"`
 	References []ReferenceConfig `json:"references,omitempty"`
+	Watches    []WatchConfig     `json:"watches,omitempty"`
+	Filter     *FilterConfig     `json:"filter,omitempty"`
 
-	Reconciler   *ReconcilerConfig `json:"reconciler,omitempty"`
-	Finalizer    *HandlerConfig    `json:"finalizer,omitempty"`
-	ResyncPeriod string            `json:"resyncPeriod,omitempty"`
+	Reconciler         *ReconcilerConfig `json:"reconciler,omitempty"`
+	Finalizer          *HandlerConfig    `json:"finalizer,omitempty"`
+	ResyncPeriod       string            `json:"resyncPeriod,omitempty"`
+	StatusSubresource  bool              `json:"statusSubresource,omitempty"`
+	ObservedGeneration bool              `json:"observedGeneration,omitempty"`
 
 	Validator *HandlerConfig  `json:"validator,omitempty"`
 	Mutator   *HandlerConfig  `json:"mutator,omitempty"`
@@ -75,9 +144,19 @@ func (c *ResourceConfig) Validate() error {
 		return errors.New("resource is empty")
 	}
 
+	err := validateScope(c.Scope)
+	if err != nil {
+		return err
+	}
+
 	for i, dep := range c.Dependents {
-		if dep.Empty() {
-			return fmt.Errorf("dependents[%d] is empty", i)
+		err := dep.Validate()
+		if err != nil {
+			return fmt.Errorf("dependents[%d]: %v", i, err)
+		}
+
+		if !c.IsClusterScoped() && dep.IsClusterScoped() {
+			return fmt.Errorf("dependents[%d]: namespaced resource cannot own cluster-scoped resource", i)
 		}
 	}
 
@@ -88,11 +167,38 @@ func (c *ResourceConfig) Validate() error {
 		}
 	}
 
+	for i, w := range c.Watches {
+		err := w.Validate()
+		if err != nil {
+			return fmt.Errorf("watches[%d]: %v", i, err)
+		}
+	}
+
+	if c.Filter != nil {
+		err := c.Filter.Validate()
+		if err != nil {
+			return fmt.Errorf("filter: %v", err)
+		}
+	}
+
 	if c.Reconciler != nil {
 		err := c.Reconciler.Validate()
 		if err != nil {
 			return fmt.Errorf("reconciler: %v", err)
 		}
+
+		if c.Reconciler.Template != nil {
+			err := c.validateTemplate(c.Reconciler.Template)
+			if err != nil {
+				return fmt.Errorf("reconciler: %v", err)
+			}
+		}
+	}
+
+	// Without the status subresource, updating the status changes the
+	// generation of the custom resource.
+	if c.ObservedGeneration && !c.StatusSubresource {
+		return errors.New("observedGeneration requires statusSubresource")
 	}
 
 	if c.Finalizer != nil {
@@ -100,6 +206,10 @@ func (c *ResourceConfig) Validate() error {
 		if err != nil {
 			return fmt.Errorf("finalizer: %v", err)
 		}
+
+		if c.Finalizer.Template != nil {
+			return errors.New("finalizer: template handler is not supported")
+		}
 	}
 
 	if c.ResyncPeriod != "" {
@@ -114,6 +224,10 @@ func (c *ResourceConfig) Validate() error {
 		if err != nil {
 			return fmt.Errorf("validator: %v", err)
 		}
+
+		if c.Validator.Template != nil {
+			return errors.New("validator: template handler is not supported")
+		}
 	}
 
 	if c.Mutator != nil {
@@ -121,6 +235,10 @@ func (c *ResourceConfig) Validate() error {
 		if err != nil {
 			return fmt.Errorf("mutator: %v", err)
 		}
+
+		if c.Mutator.Template != nil {
+			return errors.New("mutator: template handler is not supported")
+		}
 	}
 
 	if c.Injector != nil {
@@ -128,14 +246,88 @@ func (c *ResourceConfig) Validate() error {
 		if err != nil {
 			return fmt.Errorf("injector: %v", err)
 		}
+
+		if c.Injector.Template != nil {
+			return errors.New("injector: template handler is not supported")
+		}
 	}
 
 	return nil
 }
 
+const (
+	// UpdateStrategyUpdate replaces the whole dependent resource.
+	UpdateStrategyUpdate = "update"
+	// UpdateStrategyMerge patches the dependent resource with JSON merge patch.
+	UpdateStrategyMerge = "merge"
+	// UpdateStrategyStrategicMerge patches the dependent resource with
+	// strategic merge patch.
+	UpdateStrategyStrategicMerge = "strategicMerge"
+	// UpdateStrategyApply patches the dependent resource with server-side apply.
+	UpdateStrategyApply = "apply"
+)
+
+// validateTemplate validates that the templates are specified only for
+// the dependent resources.
+func (c *ResourceConfig) validateTemplate(t *TemplateHandlerConfig) error {
+	keys := map[string]struct{}{}
+	for _, dep := range c.Dependents {
+		keys[state.ResourceKey(dep.GroupVersionKind)] = struct{}{}
+	}
+
+	for key := range t.Dependents {
+		_, ok := keys[key]
+		if !ok {
+			return fmt.Errorf("template: dependents[%s]: unknown dependent resource", key)
+		}
+	}
+
+	return nil
+}
+
+// validateNamespaces validates the resource against the namespaces
+// handled by the controller. The cache restricted to the namespaces
+// cannot read cluster-scoped resources and the resources in the other
+// namespaces.
+func (c *ResourceConfig) validateNamespaces(namespaces map[string]struct{}) error {
+	if c.IsClusterScoped() {
+		return errors.New("cluster-scoped resource cannot be used with namespaces")
+	}
+
+	for i, dep := range c.Dependents {
+		if dep.IsClusterScoped() {
+			return fmt.Errorf("dependents[%d]: cluster-scoped resource cannot be used with namespaces", i)
+		}
+	}
+
+	for i, ref := range c.References {
+		for j, ns := range ref.AllowedNamespaces {
+			_, ok := namespaces[ns]
+			if !ok {
+				return fmt.Errorf("references[%d]: allowedNamespaces[%d] is not in namespaces", i, j)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsClusterScoped returns whether the resource is cluster-scoped.
+func (c *ResourceConfig) IsClusterScoped() bool {
+	return c.Scope == ScopeCluster
+}
+
 type DependentConfig struct {
 	schema.GroupVersionKind
-	Orphan bool `json:"orphan"`
+	Scope          string        `json:"scope,omitempty"`
+	Orphan         bool          `json:"orphan"`
+	UpdateStrategy string        `json:"updateStrategy,omitempty"`
+	Filter         *FilterConfig `json:"filter,omitempty"`
+}
+
+// IsClusterScoped returns whether the dependent resource is cluster-scoped.
+func (c *DependentConfig) IsClusterScoped() bool {
+	return c.Scope == ScopeCluster
 }
 
 func (c *DependentConfig) Validate() error {
@@ -143,12 +335,78 @@ func (c *DependentConfig) Validate() error {
 		return errors.New("resource is empty")
 	}
 
+	err := validateScope(c.Scope)
+	if err != nil {
+		return err
+	}
+
+	switch c.UpdateStrategy {
+	case "", UpdateStrategyUpdate, UpdateStrategyMerge, UpdateStrategyStrategicMerge, UpdateStrategyApply:
+	default:
+		return fmt.Errorf("invalid update strategy: %s", c.UpdateStrategy)
+	}
+
+	if c.Filter != nil {
+		err := c.Filter.Validate()
+		if err != nil {
+			return fmt.Errorf("filter: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// FilterConfig defines the conditions of the events that trigger
+// reconciliation. Events that do not match all conditions are ignored.
+type FilterConfig struct {
+	GenerationChanged bool                  `json:"generationChanged,omitempty"`
+	LabelSelector     *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	Annotations       []string              `json:"annotations,omitempty"`
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	ExcludeNamespaces []string              `json:"excludeNamespaces,omitempty"`
+	IgnoreDelete      bool                  `json:"ignoreDelete,omitempty"`
+}
+
+func (c *FilterConfig) Validate() error {
+	if c.LabelSelector != nil {
+		_, err := metav1.LabelSelectorAsSelector(c.LabelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid labelSelector: %v", err)
+		}
+	}
+
+	for i, key := range c.Annotations {
+		if key == "" {
+			return fmt.Errorf("annotations[%d] is empty", i)
+		}
+	}
+
+	included := map[string]bool{}
+	for i, ns := range c.Namespaces {
+		if ns == "" {
+			return fmt.Errorf("namespaces[%d] is empty", i)
+		}
+		included[ns] = true
+	}
+
+	for i, ns := range c.ExcludeNamespaces {
+		if ns == "" {
+			return fmt.Errorf("excludeNamespaces[%d] is empty", i)
+		}
+		if included[ns] {
+			return fmt.Errorf("namespace '%s' is both included and excluded", ns)
+		}
+	}
+
 	return nil
 }
 
 type ReferenceConfig struct {
 	schema.GroupVersionKind
-	NameFieldPath string `json:"nameFieldPath"`
+	NameFieldPath      string   `json:"nameFieldPath,omitempty"`
+	SelectorFieldPath  string   `json:"selectorFieldPath,omitempty"`
+	NamespaceFieldPath string   `json:"namespaceFieldPath,omitempty"`
+	AllowedNamespaces  []string `json:"allowedNamespaces,omitempty"`
 }
 
 func (c *ReferenceConfig) Validate() error {
@@ -156,8 +414,75 @@ func (c *ReferenceConfig) Validate() error {
 		return errors.New("resource is empty")
 	}
 
-	if c.NameFieldPath == "" {
-		return errors.New("nameFieldPath must be specified")
+	if c.NameFieldPath == "" && c.SelectorFieldPath == "" {
+		return errors.New("nameFieldPath or selectorFieldPath must be specified")
+	}
+
+	if c.NameFieldPath != "" && c.SelectorFieldPath != "" {
+		return errors.New("nameFieldPath and selectorFieldPath cannot be specified at the same time")
+	}
+
+	for i, ns := range c.AllowedNamespaces {
+		if ns == "" {
+			return fmt.Errorf("allowedNamespaces[%d] is empty", i)
+		}
+	}
+
+	return nil
+}
+
+// IsAllowedNamespace returns whether the resource in the namespace can
+// be referenced from the resource in the owner namespace. The resource in
+// the same namespace can always be referenced.
+func (c *ReferenceConfig) IsAllowedNamespace(owner, namespace string) bool {
+	if owner == namespace {
+		return true
+	}
+
+	for _, ns := range c.AllowedNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WatchConfig defines a resource to be watched and the rule to map the
+// changed resource to the resources to be reconciled.
+type WatchConfig struct {
+	schema.GroupVersionKind
+	NameFieldPath string        `json:"nameFieldPath,omitempty"`
+	NameLabel     string        `json:"nameLabel,omitempty"`
+	All           bool          `json:"all,omitempty"`
+	Filter        *FilterConfig `json:"filter,omitempty"`
+}
+
+func (c *WatchConfig) Validate() error {
+	if c.GroupVersionKind.Empty() {
+		return errors.New("resource is empty")
+	}
+
+	rules := 0
+	if c.NameFieldPath != "" {
+		rules++
+	}
+	if c.NameLabel != "" {
+		rules++
+	}
+	if c.All {
+		rules++
+	}
+
+	if rules != 1 {
+		return errors.New("exactly one of nameFieldPath, nameLabel or all must be specified")
+	}
+
+	if c.Filter != nil {
+		err := c.Filter.Validate()
+		if err != nil {
+			return fmt.Errorf("filter: %v", err)
+		}
 	}
 
 	return nil
@@ -165,8 +490,11 @@ func (c *ReferenceConfig) Validate() error {
 
 type ReconcilerConfig struct {
 	HandlerConfig
-	RequeueAfter string `json:"requeueAfter"`
-	Observe      bool   `json:"observe"`
+	RequeueAfter            string           `json:"requeueAfter"`
+	Observe                 bool             `json:"observe"`
+	ConflictRetry           *RetryConfig     `json:"conflictRetry,omitempty"`
+	MaxConcurrentReconciles int              `json:"maxConcurrentReconciles,omitempty"`
+	RateLimit               *RateLimitConfig `json:"rateLimit,omitempty"`
 }
 
 func (c *ReconcilerConfig) Validate() error {
@@ -177,9 +505,95 @@ func (c *ReconcilerConfig) Validate() error {
 		}
 	}
 
+	if c.ConflictRetry != nil {
+		err := c.ConflictRetry.Validate()
+		if err != nil {
+			return fmt.Errorf("conflictRetry: %v", err)
+		}
+	}
+
+	if c.MaxConcurrentReconciles < 0 {
+		return errors.New("maxConcurrentReconciles must not be negative")
+	}
+
+	if c.RateLimit != nil {
+		err := c.RateLimit.Validate()
+		if err != nil {
+			return fmt.Errorf("rateLimit: %v", err)
+		}
+	}
+
 	return c.HandlerConfig.Validate()
 }
 
+type RetryConfig struct {
+	Attempts int    `json:"attempts"`
+	Backoff  string `json:"backoff"`
+}
+
+func (c *RetryConfig) Validate() error {
+	if c.Attempts < 1 {
+		return errors.New("attempts must be greater than 0")
+	}
+
+	if c.Backoff != "" {
+		_, err := time.ParseDuration(c.Backoff)
+		if err != nil {
+			return fmt.Errorf("invalid backoff: %v", err)
+		}
+	}
+
+	return nil
+}
+
+type RateLimitConfig struct {
+	BaseDelay  string  `json:"baseDelay,omitempty"`
+	MaxDelay   string  `json:"maxDelay,omitempty"`
+	QPS        float64 `json:"qps,omitempty"`
+	Burst      int     `json:"burst,omitempty"`
+	MaxRetries int     `json:"maxRetries,omitempty"`
+}
+
+func (c *RateLimitConfig) Validate() error {
+	var (
+		baseDelay time.Duration
+		maxDelay  time.Duration
+		err       error
+	)
+
+	if c.BaseDelay != "" {
+		baseDelay, err = time.ParseDuration(c.BaseDelay)
+		if err != nil {
+			return fmt.Errorf("invalid baseDelay: %v", err)
+		}
+	}
+
+	if c.MaxDelay != "" {
+		maxDelay, err = time.ParseDuration(c.MaxDelay)
+		if err != nil {
+			return fmt.Errorf("invalid maxDelay: %v", err)
+		}
+	}
+
+	if c.BaseDelay != "" && c.MaxDelay != "" && baseDelay > maxDelay {
+		return errors.New("baseDelay must not be greater than maxDelay")
+	}
+
+	if c.QPS < 0 {
+		return errors.New("qps must not be negative")
+	}
+
+	if c.Burst < 0 {
+		return errors.New("burst must not be negative")
+	}
+
+	if c.MaxRetries < 0 {
+		return errors.New("maxRetries must not be negative")
+	}
+
+	return nil
+}
+
 type InjectorConfig struct {
 	HandlerConfig
 	VerifyKeyFile string `json:"verifyKeyFile"`
@@ -194,8 +608,12 @@ func (c *InjectorConfig) Validate() error {
 }
 
 type HandlerConfig struct {
-	Exec *ExecHandlerConfig `json:"exec"`
-	HTTP *HTTPHandlerConfig `json:"http"`
+	Exec     *ExecHandlerConfig     `json:"exec"`
+	HTTP     *HTTPHandlerConfig     `json:"http"`
+	GRPC     *GRPCHandlerConfig     `json:"grpc"`
+	Script   *ScriptHandlerConfig   `json:"script"`
+	Wasm     *WasmHandlerConfig     `json:"wasm"`
+	Template *TemplateHandlerConfig `json:"template"`
 
 	StateHandler            handler.StateHandler            `json:"-"`
 	AdmissionRequestHandler handler.AdmissionRequestHandler `json:"-"`
@@ -211,6 +629,18 @@ func (c *HandlerConfig) Validate() error {
 	if c.HTTP != nil {
 		specified++
 	}
+	if c.GRPC != nil {
+		specified++
+	}
+	if c.Script != nil {
+		specified++
+	}
+	if c.Wasm != nil {
+		specified++
+	}
+	if c.Template != nil {
+		specified++
+	}
 	if c.StateHandler != nil || c.AdmissionRequestHandler != nil || c.InjectionRequestHandler != nil {
 		specified++
 	}
@@ -236,6 +666,34 @@ func (c *HandlerConfig) Validate() error {
 		}
 	}
 
+	if c.GRPC != nil {
+		err := c.GRPC.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Script != nil {
+		err := c.Script.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Wasm != nil {
+		err := c.Wasm.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Template != nil {
+		err := c.Template.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -246,6 +704,8 @@ type ExecHandlerConfig struct {
 	Env        map[string]string `json:"env"`
 	Timeout    string            `json:"timeout"`
 	Debug      bool              `json:"debug"`
+	Persistent bool              `json:"persistent"`
+	PoolSize   int               `json:"poolSize"`
 }
 
 func (c ExecHandlerConfig) Validate() error {
@@ -253,6 +713,14 @@ func (c ExecHandlerConfig) Validate() error {
 		return errors.New("command must be specified")
 	}
 
+	if c.PoolSize < 0 {
+		return errors.New("poolSize must be greater than or equal to 0")
+	}
+
+	if c.PoolSize > 0 && !c.Persistent {
+		return errors.New("poolSize requires persistent to be enabled")
+	}
+
 	if c.Timeout != "" {
 		_, err := time.ParseDuration(c.Timeout)
 		if err != nil {
@@ -285,6 +753,115 @@ func (c HTTPHandlerConfig) Validate() error {
 	return nil
 }
 
+type GRPCHandlerConfig struct {
+	// Address is 'host:port' or 'unix://<path>' of the handler server.
+	Address string     `json:"address"`
+	TLS     *TLSConfig `json:"tls,omitempty"`
+	Timeout string     `json:"timeout"`
+	Debug   bool       `json:"debug"`
+}
+
+func (c GRPCHandlerConfig) Validate() error {
+	if c.Address == "" {
+		return errors.New("address must be specified")
+	}
+
+	if c.TLS != nil {
+		err := c.TLS.Validate()
+		if err != nil {
+			return fmt.Errorf("tls: %v", err)
+		}
+	}
+
+	if c.Timeout != "" {
+		_, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+
+	return nil
+}
+
+type ScriptHandlerConfig struct {
+	File     string `json:"file"`
+	Source   string `json:"source"`
+	MaxSteps uint64 `json:"maxSteps"`
+	Timeout  string `json:"timeout"`
+	Debug    bool   `json:"debug"`
+}
+
+func (c ScriptHandlerConfig) Validate() error {
+	if c.File == "" && c.Source == "" {
+		return errors.New("file or source must be specified")
+	}
+
+	if c.File != "" && c.Source != "" {
+		return errors.New("only one of file or source can be specified")
+	}
+
+	if c.Timeout != "" {
+		_, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// The maximum memory size of WebAssembly module in MiB.
+const maxWasmMemory = 4096
+
+type WasmHandlerConfig struct {
+	File      string            `json:"file"`
+	Args      []string          `json:"args"`
+	Env       map[string]string `json:"env"`
+	MaxMemory int               `json:"maxMemory"`
+	MaxCalls  uint64            `json:"maxCalls"`
+	CacheDir  string            `json:"cacheDir"`
+	Timeout   string            `json:"timeout"`
+	Debug     bool              `json:"debug"`
+}
+
+func (c WasmHandlerConfig) Validate() error {
+	if c.File == "" {
+		return errors.New("file must be specified")
+	}
+
+	if c.MaxMemory < 0 || c.MaxMemory > maxWasmMemory {
+		return fmt.Errorf("maxMemory must be between 0 and %d", maxWasmMemory)
+	}
+
+	if c.Timeout != "" {
+		_, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+
+	return nil
+}
+
+type TemplateHandlerConfig struct {
+	Dependents map[string]string `json:"dependents"`
+	Debug      bool              `json:"debug"`
+}
+
+func (c TemplateHandlerConfig) Validate() error {
+	if len(c.Dependents) == 0 {
+		return errors.New("at least one dependent must be specified")
+	}
+
+	for key, tmpl := range c.Dependents {
+		if tmpl == "" {
+			return fmt.Errorf("dependents[%s]: template must be specified", key)
+		}
+	}
+
+	return nil
+}
+
 type FuncHandlerConfig struct {
 	Handler handler.Handler `json:"-"`
 }
@@ -318,10 +895,101 @@ func (c *ServerConfig) Validate() error {
 	return nil
 }
 
+type MetricsConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	Path string `json:"path"`
+}
+
+func (c *MetricsConfig) Validate() error {
+	if c.Port == 0 {
+		return errors.New("port must be specified")
+	}
+
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return errors.New("path must start with '/'")
+	}
+
+	return nil
+}
+
+type HealthConfig struct {
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	LivenessPath  string `json:"livenessPath"`
+	ReadinessPath string `json:"readinessPath"`
+}
+
+func (c *HealthConfig) Validate() error {
+	if c.Port == 0 {
+		return errors.New("port must be specified")
+	}
+
+	if c.LivenessPath != "" && !strings.HasPrefix(c.LivenessPath, "/") {
+		return errors.New("livenessPath must start with '/'")
+	}
+
+	if c.ReadinessPath != "" && !strings.HasPrefix(c.ReadinessPath, "/") {
+		return errors.New("readinessPath must start with '/'")
+	}
+
+	if c.LivenessPath != "" && c.LivenessPath == c.ReadinessPath {
+		return errors.New("livenessPath and readinessPath must be different")
+	}
+
+	return nil
+}
+
+type LeaderElectionConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Namespace     string `json:"namespace"`
+	ID            string `json:"id"`
+	LeaseDuration string `json:"leaseDuration"`
+	RenewDeadline string `json:"renewDeadline"`
+	RetryPeriod   string `json:"retryPeriod"`
+}
+
+func (c *LeaderElectionConfig) Validate() error {
+	durations := map[string]string{
+		"leaseDuration": c.LeaseDuration,
+		"renewDeadline": c.RenewDeadline,
+		"retryPeriod":   c.RetryPeriod,
+	}
+
+	for key, val := range durations {
+		if val == "" {
+			continue
+		}
+
+		_, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// DryRunConfig is the configuration of dry-run mode. In dry-run mode,
+// the changes of the resources are logged instead of being written.
+type DryRunConfig struct {
+	Enabled    bool `json:"enabled"`
+	ServerSide bool `json:"serverSide,omitempty"`
+}
+
+func (c *DryRunConfig) Validate() error {
+	if c.ServerSide && !c.Enabled {
+		return errors.New("serverSide requires enabled")
+	}
+
+	return nil
+}
+
 type TLSConfig struct {
 	CertFile   string `json:"certFile"`
 	KeyFile    string `json:"keyFile"`
 	CACertFile string `json:"caCertFile"`
+	ServerName string `json:"serverName,omitempty"`
 }
 
 func (c *TLSConfig) Validate() error {
@@ -335,3 +1003,13 @@ func (c *TLSConfig) Validate() error {
 
 	return nil
 }
+
+func validateScope(scope string) error {
+	switch scope {
+	case "", ScopeNamespaced, ScopeCluster:
+	default:
+		return fmt.Errorf("invalid scope: %s", scope)
+	}
+
+	return nil
+}
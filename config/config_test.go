@@ -5,6 +5,7 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -21,6 +22,48 @@ func TestConfigValidate(t *testing.T) {
 	err = c.Validate()
 	Expect(err).NotTo(HaveOccurred())
 
+	// Valid namespaces
+	c = newTestConfig()
+	c.Namespaces = []string{"default", "test"}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Empty namespace
+	c = newTestConfig()
+	c.Namespaces = []string{""}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Duplicated namespaces
+	c = newTestConfig()
+	c.Namespaces = []string{"default", "default"}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Cluster-scoped resource with namespaces
+	c = newTestConfig()
+	c.Namespaces = []string{"default"}
+	c.Resources[0].Scope = ScopeCluster
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Reference to the namespace not in namespaces
+	c = newTestConfig()
+	c.Namespaces = []string{"default"}
+	c.Resources[0].References = []ReferenceConfig{
+		{
+			GroupVersionKind:  schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+			NameFieldPath:     ".spec.secretName",
+			AllowedNamespaces: []string{"shared"},
+		},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	c.Namespaces = []string{"default", "shared"}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
 	// No resources
 	c = newTestConfig()
 	c.Resources = []*ResourceConfig{}
@@ -38,6 +81,30 @@ func TestConfigValidate(t *testing.T) {
 	c.Webhook.Port = 0
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Invalid metrics
+	c = newTestConfig()
+	c.Metrics = &MetricsConfig{}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid health
+	c = newTestConfig()
+	c.Health = &HealthConfig{}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid leader election
+	c = newTestConfig()
+	c.LeaderElection = &LeaderElectionConfig{LeaseDuration: "invalid"}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid dry-run
+	c = newTestConfig()
+	c.DryRun = &DryRunConfig{ServerSide: true}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestResourceConfigValidate(t *testing.T) {
@@ -59,12 +126,44 @@ func TestResourceConfigValidate(t *testing.T) {
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
 
+	// Invalid scope
+	c = newTestConfig().Resources[0]
+	c.Scope = "invalid"
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
 	// Invalid dependents
 	c = newTestConfig().Resources[0]
 	c.Dependents[0].GroupVersionKind = schema.GroupVersionKind{}
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
 
+	// Valid observed generation
+	c = newTestConfig().Resources[0]
+	c.ObservedGeneration = true
+	c.StatusSubresource = true
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Observed generation without status subresource
+	c = newTestConfig().Resources[0]
+	c.ObservedGeneration = true
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Cluster-scoped dependents of cluster-scoped resource
+	c = newTestConfig().Resources[0]
+	c.Scope = ScopeCluster
+	c.Dependents[0].Scope = ScopeCluster
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Cluster-scoped dependents of namespaced resource
+	c = newTestConfig().Resources[0]
+	c.Dependents[0].Scope = ScopeCluster
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
 	// Invalid references
 	c = newTestConfig().Resources[0]
 	c.References[0].GroupVersionKind = schema.GroupVersionKind{}
@@ -106,6 +205,33 @@ func TestResourceConfigValidate(t *testing.T) {
 	c.Injector.Exec = nil
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Valid template reconciler
+	c = newTestConfig().Resources[0]
+	c.Reconciler.Exec = nil
+	c.Reconciler.Template = &TemplateHandlerConfig{
+		Dependents: map[string]string{"resourcea.v1alpha1.example.org": "kind: ResourceA"},
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Template for unknown dependent
+	c = newTestConfig().Resources[0]
+	c.Reconciler.Exec = nil
+	c.Reconciler.Template = &TemplateHandlerConfig{
+		Dependents: map[string]string{"unknown.v1": "kind: Unknown"},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Template validator
+	c = newTestConfig().Resources[0]
+	c.Validator.Exec = nil
+	c.Validator.Template = &TemplateHandlerConfig{
+		Dependents: map[string]string{"resourcea.v1alpha1.example.org": "kind: ResourceA"},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestDependentConfigValidate(t *testing.T) {
@@ -126,6 +252,122 @@ func TestDependentConfigValidate(t *testing.T) {
 	c.GroupVersionKind = schema.GroupVersionKind{}
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Invalid scope
+	c = newTestConfig().Resources[0].Dependents[0]
+	c.Scope = "invalid"
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Valid update strategy
+	c = newTestConfig().Resources[0].Dependents[0]
+	c.UpdateStrategy = UpdateStrategyApply
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid update strategy
+	c = newTestConfig().Resources[0].Dependents[0]
+	c.UpdateStrategy = "invalid"
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestFilterConfigValidate(t *testing.T) {
+	var (
+		err error
+		c   *FilterConfig
+	)
+
+	RegisterTestingT(t)
+
+	// Valid
+	c = &FilterConfig{
+		GenerationChanged: true,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "test"},
+		},
+		Annotations:       []string{"example.com/enabled"},
+		Namespaces:        []string{"default"},
+		ExcludeNamespaces: []string{"kube-system"},
+		IgnoreDelete:      true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid label selector
+	c = &FilterConfig{
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: "Invalid"},
+			},
+		},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Empty annotation
+	c = &FilterConfig{Annotations: []string{""}}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Empty namespace
+	c = &FilterConfig{Namespaces: []string{""}}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Empty exclude namespace
+	c = &FilterConfig{ExcludeNamespaces: []string{""}}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Included and excluded namespace
+	c = &FilterConfig{Namespaces: []string{"default"}, ExcludeNamespaces: []string{"default"}}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestWatchConfigValidate(t *testing.T) {
+	var (
+		err error
+		c   *WatchConfig
+	)
+
+	RegisterTestingT(t)
+
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+
+	// Valid
+	c = &WatchConfig{GroupVersionKind: gvk, All: true}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	c = &WatchConfig{GroupVersionKind: gvk, NameFieldPath: ".metadata.annotations.parent"}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	c = &WatchConfig{GroupVersionKind: gvk, NameLabel: "parent"}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Empty GVK
+	c = &WatchConfig{All: true}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// No mapping rule
+	c = &WatchConfig{GroupVersionKind: gvk}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Multiple mapping rules
+	c = &WatchConfig{GroupVersionKind: gvk, NameLabel: "parent", All: true}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid filter
+	c = &WatchConfig{GroupVersionKind: gvk, All: true, Filter: &FilterConfig{Annotations: []string{""}}}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestReferenceConfigValidate(t *testing.T) {
@@ -152,6 +394,36 @@ func TestReferenceConfigValidate(t *testing.T) {
 	c.NameFieldPath = ""
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Selector field path
+	c = newTestConfig().Resources[0].References[0]
+	c.NameFieldPath = ""
+	c.SelectorFieldPath = ".spec.selector"
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Both of name and selector field path
+	c = newTestConfig().Resources[0].References[0]
+	c.SelectorFieldPath = ".spec.selector"
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Empty allowed namespace
+	c = newTestConfig().Resources[0].References[0]
+	c.AllowedNamespaces = []string{""}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestReferenceConfigIsAllowedNamespace(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := newTestConfig().Resources[0].References[0]
+	c.AllowedNamespaces = []string{"shared"}
+
+	Expect(c.IsAllowedNamespace("default", "default")).To(BeTrue())
+	Expect(c.IsAllowedNamespace("default", "shared")).To(BeTrue())
+	Expect(c.IsAllowedNamespace("default", "kube-system")).To(BeFalse())
 }
 
 func TestReconcilerConfigValidate(t *testing.T) {
@@ -178,6 +450,112 @@ func TestReconcilerConfigValidate(t *testing.T) {
 	c.HandlerConfig.Exec = nil
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Invalid conflict retry
+	c = newTestConfig().Resources[0].Reconciler
+	c.ConflictRetry = &RetryConfig{Attempts: 0}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Valid max concurrent reconciles
+	c = newTestConfig().Resources[0].Reconciler
+	c.MaxConcurrentReconciles = 4
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid max concurrent reconciles
+	c = newTestConfig().Resources[0].Reconciler
+	c.MaxConcurrentReconciles = -1
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid rate limit
+	c = newTestConfig().Resources[0].Reconciler
+	c.RateLimit = &RateLimitConfig{MaxRetries: -1}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	var (
+		err error
+		c   *RateLimitConfig
+	)
+
+	RegisterTestingT(t)
+
+	// Valid
+	c = &RateLimitConfig{BaseDelay: "10ms", MaxDelay: "5m", QPS: 10, Burst: 100, MaxRetries: 5}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Empty
+	c = &RateLimitConfig{}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid base delay
+	c = &RateLimitConfig{BaseDelay: "invalid"}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid max delay
+	c = &RateLimitConfig{MaxDelay: "invalid"}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Base delay greater than max delay
+	c = &RateLimitConfig{BaseDelay: "10m", MaxDelay: "1m"}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Negative QPS
+	c = &RateLimitConfig{QPS: -1}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Negative burst
+	c = &RateLimitConfig{Burst: -1}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Negative max retries
+	c = &RateLimitConfig{MaxRetries: -1}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestRetryConfigValidate(t *testing.T) {
+	var (
+		err error
+		c   *RetryConfig
+	)
+
+	RegisterTestingT(t)
+
+	// Valid
+	c = &RetryConfig{
+		Attempts: 3,
+		Backoff:  "100ms",
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid attempts
+	c = &RetryConfig{
+		Attempts: 0,
+		Backoff:  "100ms",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid backoff
+	c = &RetryConfig{
+		Attempts: 3,
+		Backoff:  "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestInjectorConfigValidate(t *testing.T) {
@@ -254,6 +632,34 @@ func TestHandlerConfig(t *testing.T) {
 	}
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Invalid gRPC handler
+	c = &HandlerConfig{
+		GRPC: &GRPCHandlerConfig{},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid script handler
+	c = &HandlerConfig{
+		Script: &ScriptHandlerConfig{},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid wasm handler
+	c = &HandlerConfig{
+		Wasm: &WasmHandlerConfig{},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid template handler
+	c = &HandlerConfig{
+		Template: &TemplateHandlerConfig{},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestExecHandlerConfig(t *testing.T) {
@@ -289,6 +695,32 @@ func TestExecHandlerConfig(t *testing.T) {
 	}
 	err = c.Validate()
 	Expect(err).To(HaveOccurred())
+
+	// Valid persistent
+	c = &ExecHandlerConfig{
+		Command:    "/bin/controller",
+		Persistent: true,
+		PoolSize:   4,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid pool size
+	c = &ExecHandlerConfig{
+		Command:    "/bin/controller",
+		Persistent: true,
+		PoolSize:   -1,
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Pool size without persistent
+	c = &ExecHandlerConfig{
+		Command:  "/bin/controller",
+		PoolSize: 4,
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
 }
 
 func TestHTTPHandlerConfig(t *testing.T) {
@@ -328,6 +760,168 @@ func TestHTTPHandlerConfig(t *testing.T) {
 	Expect(err).To(HaveOccurred())
 }
 
+func TestGRPCHandlerConfig(t *testing.T) {
+	var (
+		err error
+		c   *GRPCHandlerConfig
+	)
+
+	// Valid
+	c = &GRPCHandlerConfig{
+		Address: "unix:///var/run/handler.sock",
+		TLS: &TLSConfig{
+			CertFile:   "client.pem",
+			KeyFile:    "client-key.pem",
+			CACertFile: "ca.pem",
+		},
+		Timeout: "30s",
+		Debug:   true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid address
+	c = &GRPCHandlerConfig{
+		Address: "",
+		Timeout: "30s",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid TLS
+	c = &GRPCHandlerConfig{
+		Address: "127.0.0.1:9000",
+		TLS: &TLSConfig{
+			KeyFile: "client-key.pem",
+		},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid timeout
+	c = &GRPCHandlerConfig{
+		Address: "127.0.0.1:9000",
+		Timeout: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestScriptHandlerConfig(t *testing.T) {
+	var (
+		err error
+		c   *ScriptHandlerConfig
+	)
+
+	// Valid
+	c = &ScriptHandlerConfig{
+		File:     "reconciler.star",
+		MaxSteps: 100000,
+		Timeout:  "10s",
+		Debug:    true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// No script
+	c = &ScriptHandlerConfig{
+		Timeout: "10s",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Both file and source
+	c = &ScriptHandlerConfig{
+		File:   "reconciler.star",
+		Source: "def handle(state):\n  return state\n",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid timeout
+	c = &ScriptHandlerConfig{
+		Source:  "def handle(state):\n  return state\n",
+		Timeout: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestWasmHandlerConfig(t *testing.T) {
+	var (
+		err error
+		c   *WasmHandlerConfig
+	)
+
+	// Valid
+	c = &WasmHandlerConfig{
+		File:      "reconciler.wasm",
+		Args:      []string{"reconcile"},
+		Env:       map[string]string{"name": "value"},
+		MaxMemory: 64,
+		MaxCalls:  100000,
+		CacheDir:  "/tmp/cache",
+		Timeout:   "10s",
+		Debug:     true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid file
+	c = &WasmHandlerConfig{
+		File: "",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid max memory
+	c = &WasmHandlerConfig{
+		File:      "reconciler.wasm",
+		MaxMemory: 8192,
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid timeout
+	c = &WasmHandlerConfig{
+		File:    "reconciler.wasm",
+		Timeout: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestTemplateHandlerConfig(t *testing.T) {
+	var (
+		err error
+		c   *TemplateHandlerConfig
+	)
+
+	// Valid
+	c = &TemplateHandlerConfig{
+		Dependents: map[string]string{
+			"deployment.v1.apps": "kind: Deployment",
+		},
+		Debug: true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// No dependents
+	c = &TemplateHandlerConfig{}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Empty template
+	c = &TemplateHandlerConfig{
+		Dependents: map[string]string{
+			"deployment.v1.apps": "",
+		},
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
 func TestServerConfig(t *testing.T) {
 	var (
 		err error
@@ -369,6 +963,154 @@ func TestServerConfig(t *testing.T) {
 	Expect(err).To(HaveOccurred())
 }
 
+func TestMetricsConfig(t *testing.T) {
+	var (
+		err error
+		c   *MetricsConfig
+	)
+
+	// Valid
+	c = &MetricsConfig{
+		Host: "127.0.0.1",
+		Port: 8080,
+		Path: "/metrics",
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid port
+	c = &MetricsConfig{
+		Host: "127.0.0.1",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid path
+	c = &MetricsConfig{
+		Host: "127.0.0.1",
+		Port: 8080,
+		Path: "metrics",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestHealthConfig(t *testing.T) {
+	var (
+		err error
+		c   *HealthConfig
+	)
+
+	// Valid
+	c = &HealthConfig{
+		Host:          "127.0.0.1",
+		Port:          8081,
+		LivenessPath:  "/healthz",
+		ReadinessPath: "/readyz",
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid port
+	c = &HealthConfig{
+		Host: "127.0.0.1",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid liveness path
+	c = &HealthConfig{
+		Port:         8081,
+		LivenessPath: "healthz",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid readiness path
+	c = &HealthConfig{
+		Port:          8081,
+		ReadinessPath: "readyz",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Same paths
+	c = &HealthConfig{
+		Port:          8081,
+		LivenessPath:  "/healthz",
+		ReadinessPath: "/healthz",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestLeaderElectionConfig(t *testing.T) {
+	var (
+		err error
+		c   *LeaderElectionConfig
+	)
+
+	// Valid
+	c = &LeaderElectionConfig{
+		Enabled:       true,
+		Namespace:     "default",
+		ID:            "test",
+		LeaseDuration: "15s",
+		RenewDeadline: "10s",
+		RetryPeriod:   "2s",
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Invalid lease duration
+	c = &LeaderElectionConfig{
+		Enabled:       true,
+		LeaseDuration: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid renew deadline
+	c = &LeaderElectionConfig{
+		Enabled:       true,
+		RenewDeadline: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+
+	// Invalid retry period
+	c = &LeaderElectionConfig{
+		Enabled:     true,
+		RetryPeriod: "invalid",
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
+func TestDryRunConfig(t *testing.T) {
+	var (
+		err error
+		c   *DryRunConfig
+	)
+
+	RegisterTestingT(t)
+
+	// Valid
+	c = &DryRunConfig{
+		Enabled:    true,
+		ServerSide: true,
+	}
+	err = c.Validate()
+	Expect(err).NotTo(HaveOccurred())
+
+	// Server-side without enabled
+	c = &DryRunConfig{
+		ServerSide: true,
+	}
+	err = c.Validate()
+	Expect(err).To(HaveOccurred())
+}
+
 func TestTLSConfig(t *testing.T) {
 	var (
 		err error
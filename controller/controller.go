@@ -1,21 +1,35 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/controller/filter"
 	"github.com/summerwind/whitebox-controller/controller/syncer"
+	"github.com/summerwind/whitebox-controller/handler/common"
 	"github.com/summerwind/whitebox-controller/reconciler"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
 )
 
-func New(c *config.ResourceConfig, mgr manager.Manager) (*controller.Controller, error) {
+var log = logf.Log.WithName("controller")
+
+// New returns a new controller for the resource. If namespaces are
+// specified, the controller handles only the resources in the namespaces.
+func New(c *config.ResourceConfig, mgr manager.Manager, namespaces []string) (*controller.Controller, error) {
 	var (
 		r   *reconciler.Reconciler
 		err error
@@ -28,7 +42,29 @@ func New(c *config.ResourceConfig, mgr manager.Manager) (*controller.Controller,
 		return nil, fmt.Errorf("could not create reconciler: %v", err)
 	}
 
-	ctrl, err := controller.New(name, mgr, controller.Options{Reconciler: r})
+	// The predicates of the filter apply only to the events of the
+	// resource, but the resources are also enqueued by the dependents,
+	// the references, the watches and the syncer. The reconciler checks
+	// the filter again so that these resources are excluded as well.
+	m, err := filter.NewMatcher(c.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+	r.SetFilter(m)
+
+	for _, h := range r.Handlers() {
+		err := common.AddRunnable(mgr, h)
+		if err != nil {
+			return nil, fmt.Errorf("could not add handler: %v", err)
+		}
+	}
+
+	opts := controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: c.Reconciler.MaxConcurrentReconciles,
+	}
+
+	ctrl, err := controller.New(name, mgr, opts)
 	if err != nil {
 		return nil, fmt.Errorf("could not create controller: %v", err)
 	}
@@ -36,11 +72,21 @@ func New(c *config.ResourceConfig, mgr manager.Manager) (*controller.Controller,
 	obj := &unstructured.Unstructured{}
 	obj.SetGroupVersionKind(c.GroupVersionKind)
 
-	err = ctrl.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForObject{})
+	preds, err := filter.New(c.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+
+	err = ctrl.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForObject{}, preds...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to watch resource: %v", err)
 	}
 
+	err = watchResources(ctrl, c, mgr, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
 	// No need to setup deps and syncer for observer.
 	if r.IsObserver() {
 		return &ctrl, nil
@@ -50,17 +96,27 @@ func New(c *config.ResourceConfig, mgr manager.Manager) (*controller.Controller,
 		depObj := &unstructured.Unstructured{}
 		depObj.SetGroupVersionKind(dep.GroupVersionKind)
 
+		depPreds, err := filter.New(dep.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dependent filter: %v", err)
+		}
+
 		err = ctrl.Watch(&source.Kind{Type: depObj}, &handler.EnqueueRequestForOwner{
 			IsController: true,
 			OwnerType:    obj,
-		})
+		}, depPreds...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to watch dependent resource: %v", err)
 		}
 	}
 
+	err = watchReferences(ctrl, c, mgr)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.ResyncPeriod != "" {
-		s, err := syncer.New(c, mgr)
+		s, err := syncer.New(c, mgr, namespaces)
 		if err != nil {
 			return nil, fmt.Errorf("could not create syncer: %v", err)
 		}
@@ -73,3 +129,286 @@ func New(c *config.ResourceConfig, mgr manager.Manager) (*controller.Controller,
 
 	return &ctrl, nil
 }
+
+// watchReferences watches the reference resources and reconciles the
+// resources that refer to the changed reference resource. The resources
+// referring by name are looked up with the field index of the cache.
+func watchReferences(ctrl controller.Controller, c *config.ResourceConfig, mgr manager.Manager) error {
+	mappers := map[string]*referenceMapper{}
+
+	for _, ref := range c.References {
+		key := state.ResourceKey(ref.GroupVersionKind)
+
+		m, ok := mappers[key]
+		if !ok {
+			m = &referenceMapper{
+				reader: mgr.GetCache(),
+				config: c,
+				field:  fmt.Sprintf("reference.%s", key),
+			}
+			mappers[key] = m
+		}
+
+		m.references = append(m.references, ref)
+	}
+
+	for key, m := range mappers {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(c.GroupVersionKind)
+
+		err := mgr.GetFieldIndexer().IndexField(obj, m.field, m.index)
+		if err != nil {
+			return fmt.Errorf("failed to index reference resource %s: %v", key, err)
+		}
+
+		refObj := &unstructured.Unstructured{}
+		refObj.SetGroupVersionKind(m.references[0].GroupVersionKind)
+
+		err = ctrl.Watch(&source.Kind{Type: refObj}, &handler.EnqueueRequestsFromMapFunc{ToRequests: m})
+		if err != nil {
+			return fmt.Errorf("failed to watch reference resource: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// referenceMapper maps a reference resource to the resources that
+// refer to it. The resources are read from the cache because the field
+// index is available only in the cache.
+type referenceMapper struct {
+	reader     client.Reader
+	config     *config.ResourceConfig
+	references []config.ReferenceConfig
+	field      string
+}
+
+// index returns the keys of the resources referred to by name from
+// the object. It is used as the indexer function of the field index.
+func (m *referenceMapper) index(obj runtime.Object) []string {
+	res, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	keys := []string{}
+	for _, ref := range m.references {
+		refKeys, err := reconciler.ReferenceKeys(res, ref)
+		if err != nil {
+			log.Error(err, "Failed to get reference keys", "namespace", res.GetNamespace(), "name", res.GetName())
+			continue
+		}
+		keys = append(keys, refKeys...)
+	}
+
+	return keys
+}
+
+// Map implements handler.Mapper interface.
+func (m *referenceMapper) Map(o handler.MapObject) []reconcile.Request {
+	reqs := map[types.NamespacedName]bool{}
+
+	key := types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: o.Meta.GetName()}
+
+	resList := newList(m.config.GroupVersionKind)
+
+	err := m.reader.List(context.TODO(), resList, client.MatchingFields{m.field: key.String()})
+	if err != nil {
+		log.Error(err, "Failed to list resources by reference", "reference", key.String())
+		return nil
+	}
+
+	for _, res := range resList.Items {
+		reqs[types.NamespacedName{Namespace: res.GetNamespace(), Name: res.GetName()}] = true
+	}
+
+	if m.hasSelector() {
+		resList := newList(m.config.GroupVersionKind)
+
+		err := m.reader.List(context.TODO(), resList)
+		if err != nil {
+			log.Error(err, "Failed to list resources", "reference", key.String())
+			return nil
+		}
+
+		for i := range resList.Items {
+			res := &resList.Items[i]
+			for _, ref := range m.references {
+				ok, err := reconciler.SelectsReference(res, ref, o.Meta)
+				if err != nil {
+					log.Error(err, "Failed to match reference selector", "namespace", res.GetNamespace(), "name", res.GetName())
+					continue
+				}
+				if ok {
+					reqs[types.NamespacedName{Namespace: res.GetNamespace(), Name: res.GetName()}] = true
+				}
+			}
+		}
+	}
+
+	requests := []reconcile.Request{}
+	for nn := range reqs {
+		requests = append(requests, reconcile.Request{NamespacedName: nn})
+	}
+
+	return requests
+}
+
+// hasSelector returns whether any of the references uses label selector.
+func (m *referenceMapper) hasSelector() bool {
+	for _, ref := range m.references {
+		if ref.SelectorFieldPath != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchResources watches the resources specified in the watches and
+// reconciles the resources mapped from the changed resource.
+func watchResources(ctrl controller.Controller, c *config.ResourceConfig, mgr manager.Manager, namespaces []string) error {
+	for i := range c.Watches {
+		w := c.Watches[i]
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(w.GroupVersionKind)
+
+		preds, err := filter.New(w.Filter)
+		if err != nil {
+			return fmt.Errorf("invalid watch filter: %v", err)
+		}
+
+		m := &watchMapper{
+			reader:     mgr.GetCache(),
+			config:     c,
+			watch:      w,
+			namespaces: namespaces,
+		}
+
+		err = ctrl.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestsFromMapFunc{ToRequests: m}, preds...)
+		if err != nil {
+			return fmt.Errorf("failed to watch resource: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// watchMapper maps a watched resource to the resources to be reconciled.
+// If namespaces are specified, the resources in other namespaces are not
+// mapped.
+type watchMapper struct {
+	reader     client.Reader
+	config     *config.ResourceConfig
+	watch      config.WatchConfig
+	namespaces []string
+}
+
+// Map implements handler.Mapper interface.
+func (m *watchMapper) Map(o handler.MapObject) []reconcile.Request {
+	if m.watch.All {
+		return m.mapAll()
+	}
+
+	names := []string{}
+	if m.watch.NameLabel != "" {
+		name := o.Meta.GetLabels()[m.watch.NameLabel]
+		if name != "" {
+			names = append(names, name)
+		}
+	} else {
+		res, ok := o.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil
+		}
+
+		values, err := reconciler.FieldValues(res, m.watch.NameFieldPath)
+		if err != nil {
+			log.Error(err, "Failed to get resource names", "namespace", o.Meta.GetNamespace(), "name", o.Meta.GetName())
+			return nil
+		}
+
+		for _, v := range values {
+			if v != "" {
+				names = append(names, v)
+			}
+		}
+	}
+
+	requests := []reconcile.Request{}
+	for _, name := range names {
+		nn := types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: name}
+
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) == 2 {
+			nn = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		}
+
+		if m.config.IsClusterScoped() {
+			nn.Namespace = ""
+		} else if !m.isAllowedNamespace(nn.Namespace) {
+			log.Info("Ignored a watched resource mapped to the namespace not handled", "namespace", o.Meta.GetNamespace(), "name", o.Meta.GetName(), "resource", nn.String())
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: nn})
+	}
+
+	return requests
+}
+
+// isAllowedNamespace returns whether the namespaced resource in the
+// namespace can be reconciled. The namespace is empty if a cluster-scoped
+// resource is mapped by name without 'namespace/name' format.
+func (m *watchMapper) isAllowedNamespace(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+
+	if len(m.namespaces) == 0 {
+		return true
+	}
+
+	for _, ns := range m.namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapAll returns the requests for all resources.
+func (m *watchMapper) mapAll() []reconcile.Request {
+	resList := newList(m.config.GroupVersionKind)
+
+	err := m.reader.List(context.TODO(), resList)
+	if err != nil {
+		log.Error(err, "Failed to list resources")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, res := range resList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: res.GetNamespace(),
+				Name:      res.GetName(),
+			},
+		})
+	}
+
+	return requests
+}
+
+// newList returns a new list of the resources. The kind of the list
+// must have 'List' suffix to read the list from the cache.
+func newList(gvk schema.GroupVersionKind) *unstructured.UnstructuredList {
+	gvk.Kind = gvk.Kind + "List"
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk)
+
+	return list
+}
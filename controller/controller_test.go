@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+func TestWatchMapperWithNameLabel(t *testing.T) {
+	RegisterTestingT(t)
+
+	m := &watchMapper{
+		config: newResourceConfig(),
+		watch:  config.WatchConfig{NameLabel: "example.com/parent"},
+	}
+
+	pod := newPod("default", map[string]string{"example.com/parent": "test"}, nil)
+	reqs := m.Map(handler.MapObject{Meta: pod, Object: pod})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "default", Name: "test"}))
+
+	pod = newPod("default", nil, nil)
+	reqs = m.Map(handler.MapObject{Meta: pod, Object: pod})
+	Expect(reqs).To(BeEmpty())
+}
+
+func TestWatchMapperWithNameFieldPath(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	m := &watchMapper{
+		config: rc,
+		watch:  config.WatchConfig{NameFieldPath: ".metadata.annotations.parent"},
+	}
+
+	obj := newUnstructuredPod("default", map[string]string{"parent": "test"})
+	reqs := m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "default", Name: "test"}))
+
+	obj = newUnstructuredPod("default", map[string]string{"parent": "shared/test"})
+	reqs = m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "shared", Name: "test"}))
+
+	rc.Scope = config.ScopeCluster
+	reqs = m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "", Name: "test"}))
+
+	obj = newUnstructuredPod("default", nil)
+	reqs = m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(reqs).To(BeEmpty())
+}
+
+func TestWatchMapperWithClusterScopedResource(t *testing.T) {
+	RegisterTestingT(t)
+
+	m := &watchMapper{
+		config: newResourceConfig(),
+		watch:  config.WatchConfig{NameFieldPath: ".metadata.annotations.parent"},
+	}
+
+	// The cluster-scoped resource must specify the namespace of the
+	// namespaced resource.
+	node := newUnstructuredNode(map[string]string{"parent": "test"})
+	reqs := m.Map(handler.MapObject{Meta: node, Object: node})
+	Expect(reqs).To(BeEmpty())
+
+	node = newUnstructuredNode(map[string]string{"parent": "default/test"})
+	reqs = m.Map(handler.MapObject{Meta: node, Object: node})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "default", Name: "test"}))
+}
+
+func TestWatchMapperWithNamespaces(t *testing.T) {
+	RegisterTestingT(t)
+
+	m := &watchMapper{
+		config:     newResourceConfig(),
+		watch:      config.WatchConfig{NameFieldPath: ".metadata.annotations.parent"},
+		namespaces: []string{"default"},
+	}
+
+	obj := newUnstructuredPod("default", map[string]string{"parent": "test"})
+	reqs := m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(len(reqs)).To(Equal(1))
+	Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: "default", Name: "test"}))
+
+	obj = newUnstructuredPod("default", map[string]string{"parent": "other/test"})
+	reqs = m.Map(handler.MapObject{Meta: obj, Object: obj})
+	Expect(reqs).To(BeEmpty())
+}
+
+func TestWatchMapperWithAll(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+
+	items := []unstructured.Unstructured{}
+	for _, name := range []string{"test1", "test2"} {
+		obj := unstructured.Unstructured{}
+		obj.SetGroupVersionKind(rc.GroupVersionKind)
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		items = append(items, obj)
+	}
+
+	m := &watchMapper{
+		reader: &testReader{items: items},
+		config: rc,
+		watch:  config.WatchConfig{All: true},
+	}
+
+	pod := newPod("default", nil, nil)
+	reqs := m.Map(handler.MapObject{Meta: pod, Object: pod})
+	Expect(len(reqs)).To(Equal(2))
+}
+
+// testReader is a reader that returns the specified items like cache.
+type testReader struct {
+	items []unstructured.Unstructured
+}
+
+func (r *testReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return errors.New("not implemented")
+}
+
+func (r *testReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	ul, ok := list.(*unstructured.UnstructuredList)
+	if !ok {
+		return errors.New("unexpected list type")
+	}
+
+	if !strings.HasSuffix(ul.GetKind(), "List") {
+		return fmt.Errorf("non-list kind %q", ul.GetKind())
+	}
+
+	ul.Items = r.items
+	return nil
+}
+
+func newResourceConfig() *config.ResourceConfig {
+	return &config.ResourceConfig{
+		GroupVersionKind: schema.GroupVersionKind{
+			Group:   "example.com",
+			Version: "v1alpha1",
+			Kind:    "Test",
+		},
+	}
+}
+
+func newPod(namespace string, labels, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        "pod",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func newUnstructuredPod(namespace string, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace(namespace)
+	obj.SetName("pod")
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+func newUnstructuredNode(annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Node")
+	obj.SetName("node")
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
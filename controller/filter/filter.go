@@ -0,0 +1,151 @@
+package filter
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+// New returns the predicates that filter events based on the filter
+// configuration. The event is handled only if all predicates are true.
+func New(c *config.FilterConfig) ([]predicate.Predicate, error) {
+	preds := []predicate.Predicate{}
+
+	if c == nil {
+		return preds, nil
+	}
+
+	if c.GenerationChanged {
+		preds = append(preds, predicate.GenerationChangedPredicate{})
+	}
+
+	if c.IgnoreDelete {
+		preds = append(preds, predicate.Funcs{
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+		})
+	}
+
+	funcs, err := metaFuncs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range funcs {
+		preds = append(preds, newMetaPredicate(f))
+	}
+
+	return preds, nil
+}
+
+// Matcher reports whether the object matches the filter.
+type Matcher func(metav1.Object) bool
+
+// NewMatcher returns a matcher that evaluates the label selector, the
+// annotations and the namespaces of the filter against the object.
+// Unlike the predicates, it does not depend on the events, so it can
+// be used for the objects enqueued by other sources such as resync,
+// references and watches.
+func NewMatcher(c *config.FilterConfig) (Matcher, error) {
+	if c == nil {
+		return func(m metav1.Object) bool { return true }, nil
+	}
+
+	funcs, err := metaFuncs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m metav1.Object) bool {
+		for _, f := range funcs {
+			if !f(m) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// metaFuncs returns the functions that evaluate the metadata of the
+// object with the filter.
+func metaFuncs(c *config.FilterConfig) ([]func(metav1.Object) bool, error) {
+	funcs := []func(metav1.Object) bool{}
+
+	if c.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(c.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		funcs = append(funcs, func(m metav1.Object) bool {
+			return selector.Matches(labels.Set(m.GetLabels()))
+		})
+	}
+
+	if len(c.Annotations) > 0 {
+		funcs = append(funcs, func(m metav1.Object) bool {
+			annotations := m.GetAnnotations()
+			for _, key := range c.Annotations {
+				if _, ok := annotations[key]; !ok {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	if len(c.Namespaces) > 0 {
+		funcs = append(funcs, func(m metav1.Object) bool {
+			return contains(c.Namespaces, m.GetNamespace())
+		})
+	}
+
+	if len(c.ExcludeNamespaces) > 0 {
+		funcs = append(funcs, func(m metav1.Object) bool {
+			return !contains(c.ExcludeNamespaces, m.GetNamespace())
+		})
+	}
+
+	return funcs, nil
+}
+
+// newMetaPredicate returns a predicate that evaluates the metadata of
+// the object in all types of events. For update events, the metadata
+// of the new object is evaluated.
+func newMetaPredicate(f func(metav1.Object) bool) predicate.Predicate {
+	match := func(m metav1.Object) bool {
+		if m == nil {
+			return false
+		}
+		return f(m)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return match(e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return match(e.MetaNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return match(e.Meta)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return match(e.Meta)
+		},
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
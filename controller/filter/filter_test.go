@@ -0,0 +1,180 @@
+package filter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+func TestNew(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(nil)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(preds).To(BeEmpty())
+
+	preds, err = New(&config.FilterConfig{
+		GenerationChanged: true,
+		IgnoreDelete:      true,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "test"},
+		},
+		Annotations:       []string{"example.com/enabled"},
+		Namespaces:        []string{"default"},
+		ExcludeNamespaces: []string{"kube-system"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(len(preds)).To(Equal(6))
+}
+
+func TestGenerationChanged(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(&config.FilterConfig{GenerationChanged: true})
+	Expect(err).NotTo(HaveOccurred())
+
+	oldObj := newPod("default", nil, nil)
+	oldObj.SetGeneration(1)
+
+	newObj := oldObj.DeepCopy()
+	newObj.SetResourceVersion("2")
+	Expect(update(preds, oldObj, newObj)).To(BeFalse())
+
+	newObj.SetGeneration(2)
+	Expect(update(preds, oldObj, newObj)).To(BeTrue())
+}
+
+func TestIgnoreDelete(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(&config.FilterConfig{IgnoreDelete: true})
+	Expect(err).NotTo(HaveOccurred())
+
+	pod := newPod("default", nil, nil)
+	Expect(create(preds, pod)).To(BeTrue())
+	Expect(remove(preds, pod)).To(BeFalse())
+}
+
+func TestLabelSelector(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(&config.FilterConfig{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "test"},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(create(preds, newPod("default", map[string]string{"app": "test"}, nil))).To(BeTrue())
+	Expect(create(preds, newPod("default", map[string]string{"app": "other"}, nil))).To(BeFalse())
+	Expect(create(preds, newPod("default", nil, nil))).To(BeFalse())
+
+	oldObj := newPod("default", nil, nil)
+	newObj := newPod("default", map[string]string{"app": "test"}, nil)
+	Expect(update(preds, oldObj, newObj)).To(BeTrue())
+	Expect(update(preds, newObj, oldObj)).To(BeFalse())
+}
+
+func TestAnnotations(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(&config.FilterConfig{
+		Annotations: []string{"example.com/enabled"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(create(preds, newPod("default", nil, map[string]string{"example.com/enabled": ""}))).To(BeTrue())
+	Expect(create(preds, newPod("default", nil, map[string]string{"example.com/other": ""}))).To(BeFalse())
+	Expect(remove(preds, newPod("default", nil, nil))).To(BeFalse())
+}
+
+func TestNamespaces(t *testing.T) {
+	RegisterTestingT(t)
+
+	preds, err := New(&config.FilterConfig{
+		Namespaces: []string{"default"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(create(preds, newPod("default", nil, nil))).To(BeTrue())
+	Expect(create(preds, newPod("kube-system", nil, nil))).To(BeFalse())
+
+	preds, err = New(&config.FilterConfig{
+		ExcludeNamespaces: []string{"kube-system"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(create(preds, newPod("default", nil, nil))).To(BeTrue())
+	Expect(create(preds, newPod("kube-system", nil, nil))).To(BeFalse())
+}
+
+func TestNewMatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	match, err := NewMatcher(nil)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(match(newPod("kube-system", nil, nil))).To(BeTrue())
+
+	match, err = NewMatcher(&config.FilterConfig{
+		GenerationChanged: true,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "test"},
+		},
+		Annotations:       []string{"example.com/enabled"},
+		ExcludeNamespaces: []string{"kube-system"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	labels := map[string]string{"app": "test"}
+	annotations := map[string]string{"example.com/enabled": ""}
+
+	Expect(match(newPod("default", labels, annotations))).To(BeTrue())
+	Expect(match(newPod("default", nil, annotations))).To(BeFalse())
+	Expect(match(newPod("default", labels, nil))).To(BeFalse())
+	Expect(match(newPod("kube-system", labels, annotations))).To(BeFalse())
+}
+
+func create(preds []predicate.Predicate, pod *corev1.Pod) bool {
+	for _, p := range preds {
+		if !p.Create(event.CreateEvent{Meta: pod, Object: pod}) {
+			return false
+		}
+	}
+	return true
+}
+
+func update(preds []predicate.Predicate, oldObj, newObj *corev1.Pod) bool {
+	for _, p := range preds {
+		if !p.Update(event.UpdateEvent{MetaOld: oldObj, ObjectOld: oldObj, MetaNew: newObj, ObjectNew: newObj}) {
+			return false
+		}
+	}
+	return true
+}
+
+func remove(preds []predicate.Predicate, pod *corev1.Pod) bool {
+	for _, p := range preds {
+		if !p.Delete(event.DeleteEvent{Meta: pod, Object: pod}) {
+			return false
+		}
+	}
+	return true
+}
+
+func newPod(namespace string, labels, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        "test",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
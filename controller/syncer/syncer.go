@@ -20,22 +20,26 @@ var log = logf.Log.WithName("syncer")
 
 type Syncer struct {
 	client.Client
-	C        chan event.GenericEvent
-	config   *config.ResourceConfig
-	interval time.Duration
+	C          chan event.GenericEvent
+	config     *config.ResourceConfig
+	namespaces []string
+	interval   time.Duration
 }
 
-func New(c *config.ResourceConfig, mgr manager.Manager) (*Syncer, error) {
+// New returns a new syncer. If namespaces are specified, the syncer
+// syncs only the resources in the namespaces.
+func New(c *config.ResourceConfig, mgr manager.Manager, namespaces []string) (*Syncer, error) {
 	interval, err := time.ParseDuration(c.ResyncPeriod)
 	if err != nil {
 		return nil, fmt.Errorf("invalid resync period: %v", err)
 	}
 
 	s := &Syncer{
-		Client:   mgr.GetClient(),
-		C:        make(chan event.GenericEvent),
-		config:   c,
-		interval: interval,
+		Client:     mgr.GetClient(),
+		C:          make(chan event.GenericEvent),
+		config:     c,
+		namespaces: namespaces,
+		interval:   interval,
 	}
 
 	return s, mgr.Add(s)
@@ -62,20 +66,27 @@ func (s *Syncer) Start(stop <-chan struct{}) error {
 }
 
 func (s *Syncer) Sync() error {
-	instanceList := &unstructured.UnstructuredList{}
-	instanceList.SetGroupVersionKind(s.config.GroupVersionKind)
-
-	err := s.List(context.TODO(), instanceList)
-	if err != nil {
-		return err
+	namespaces := s.namespaces
+	if len(namespaces) == 0 || s.config.IsClusterScoped() {
+		namespaces = []string{metav1.NamespaceAll}
 	}
 
-	for _, instance := range instanceList.Items {
-		s.C <- event.GenericEvent{
-			Meta: &metav1.ObjectMeta{
-				Name:      instance.GetName(),
-				Namespace: instance.GetNamespace(),
-			},
+	for _, ns := range namespaces {
+		instanceList := &unstructured.UnstructuredList{}
+		instanceList.SetGroupVersionKind(s.config.GroupVersionKind)
+
+		err := s.List(context.TODO(), instanceList, client.InNamespace(ns))
+		if err != nil {
+			return err
+		}
+
+		for _, instance := range instanceList.Items {
+			s.C <- event.GenericEvent{
+				Meta: &metav1.ObjectMeta{
+					Name:      instance.GetName(),
+					Namespace: instance.GetNamespace(),
+				},
+			}
 		}
 	}
 
@@ -4,10 +4,17 @@ import (
 	"errors"
 	"os"
 
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
 	"github.com/summerwind/whitebox-controller/config"
 	"github.com/summerwind/whitebox-controller/handler"
 	"github.com/summerwind/whitebox-controller/handler/exec"
+	"github.com/summerwind/whitebox-controller/handler/grpc"
 	"github.com/summerwind/whitebox-controller/handler/http"
+	"github.com/summerwind/whitebox-controller/handler/script"
+	"github.com/summerwind/whitebox-controller/handler/template"
+	"github.com/summerwind/whitebox-controller/handler/wasm"
+	"github.com/summerwind/whitebox-controller/metrics"
 )
 
 // The name of environment variable to enable debug log.
@@ -15,8 +22,21 @@ const debugEnvVar = "WHITEBOX_DEBUG"
 
 var errNoHandler = errors.New("no handler found")
 
+// AddRunnable adds the handler to the manager if the handler implements
+// manager.Runnable. Such handlers hold the resources, like connections
+// and processes, that are released when the manager stops.
+func AddRunnable(mgr manager.Manager, h interface{}) error {
+	r, ok := h.(manager.Runnable)
+	if !ok {
+		return nil
+	}
+
+	return mgr.Add(r)
+}
+
 // NewStateHandler returns StateHandler based on specified HandlerConfig.
-func NewStateHandler(c *config.HandlerConfig) (handler.StateHandler, error) {
+// The labels are used to identify the handler in metrics.
+func NewStateHandler(c *config.HandlerConfig, l metrics.Labels) (handler.StateHandler, error) {
 	var debug bool
 
 	if c.StateHandler != nil {
@@ -29,19 +49,39 @@ func NewStateHandler(c *config.HandlerConfig) (handler.StateHandler, error) {
 
 	if c.Exec != nil {
 		c.Exec.Debug = (c.Exec.Debug || debug)
-		return exec.New(c.Exec)
+		return exec.New(c.Exec, l)
 	}
 
 	if c.HTTP != nil {
 		c.HTTP.Debug = (c.HTTP.Debug || debug)
-		return http.New(c.HTTP)
+		return http.New(c.HTTP, l)
+	}
+
+	if c.GRPC != nil {
+		c.GRPC.Debug = (c.GRPC.Debug || debug)
+		return grpc.New(c.GRPC, l)
+	}
+
+	if c.Script != nil {
+		c.Script.Debug = (c.Script.Debug || debug)
+		return script.New(c.Script, l)
+	}
+
+	if c.Wasm != nil {
+		c.Wasm.Debug = (c.Wasm.Debug || debug)
+		return wasm.New(c.Wasm, l)
+	}
+
+	if c.Template != nil {
+		c.Template.Debug = (c.Template.Debug || debug)
+		return template.New(c.Template, l)
 	}
 
 	return nil, errNoHandler
 }
 
 // NewAdmissionRequestHandler returns AdmissionRequestHandler based on specified HandlerConfig.
-func NewAdmissionRequestHandler(c *config.HandlerConfig) (handler.AdmissionRequestHandler, error) {
+func NewAdmissionRequestHandler(c *config.HandlerConfig, l metrics.Labels) (handler.AdmissionRequestHandler, error) {
 	var debug bool
 
 	if c.AdmissionRequestHandler != nil {
@@ -54,19 +94,34 @@ func NewAdmissionRequestHandler(c *config.HandlerConfig) (handler.AdmissionReque
 
 	if c.Exec != nil {
 		c.Exec.Debug = (c.Exec.Debug || debug)
-		return exec.New(c.Exec)
+		return exec.New(c.Exec, l)
 	}
 
 	if c.HTTP != nil {
 		c.HTTP.Debug = (c.HTTP.Debug || debug)
-		return http.New(c.HTTP)
+		return http.New(c.HTTP, l)
+	}
+
+	if c.GRPC != nil {
+		c.GRPC.Debug = (c.GRPC.Debug || debug)
+		return grpc.New(c.GRPC, l)
+	}
+
+	if c.Script != nil {
+		c.Script.Debug = (c.Script.Debug || debug)
+		return script.New(c.Script, l)
+	}
+
+	if c.Wasm != nil {
+		c.Wasm.Debug = (c.Wasm.Debug || debug)
+		return wasm.New(c.Wasm, l)
 	}
 
 	return nil, errNoHandler
 }
 
 // NewInjectionRequestHandler returns InjectionRequestHandler based on specified HandlerConfig.
-func NewInjectionRequestHandler(c *config.HandlerConfig) (handler.InjectionRequestHandler, error) {
+func NewInjectionRequestHandler(c *config.HandlerConfig, l metrics.Labels) (handler.InjectionRequestHandler, error) {
 	var debug bool
 
 	if c.InjectionRequestHandler != nil {
@@ -79,12 +134,27 @@ func NewInjectionRequestHandler(c *config.HandlerConfig) (handler.InjectionReque
 
 	if c.Exec != nil {
 		c.Exec.Debug = (c.Exec.Debug || debug)
-		return exec.New(c.Exec)
+		return exec.New(c.Exec, l)
 	}
 
 	if c.HTTP != nil {
 		c.HTTP.Debug = (c.HTTP.Debug || debug)
-		return http.New(c.HTTP)
+		return http.New(c.HTTP, l)
+	}
+
+	if c.GRPC != nil {
+		c.GRPC.Debug = (c.GRPC.Debug || debug)
+		return grpc.New(c.GRPC, l)
+	}
+
+	if c.Script != nil {
+		c.Script.Debug = (c.Script.Debug || debug)
+		return script.New(c.Script, l)
+	}
+
+	if c.Wasm != nil {
+		c.Wasm.Debug = (c.Wasm.Debug || debug)
+		return wasm.New(c.Wasm, l)
 	}
 
 	return nil, errNoHandler
@@ -8,18 +8,29 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
 	"github.com/summerwind/whitebox-controller/reconciler/state"
 	"github.com/summerwind/whitebox-controller/webhook/injection"
 )
 
 var log = logf.Log.WithName("handler")
 
+// The number of worker processes if the pool size is not specified.
+const defaultPoolSize = 1
+
+// ExecHandler is a handler that runs a command for each request.
+// In persistent mode, the requests are sent to the pool of long-lived
+// worker processes instead. The fields are not modified after New, and
+// the pool lends each worker to one request at a time, so concurrent
+// requests never share the stdin and stdout of a process.
 type ExecHandler struct {
 	command    string
 	args       []string
@@ -27,9 +38,11 @@ type ExecHandler struct {
 	workingDir string
 	timeout    time.Duration
 	debug      bool
+	labels     metrics.Labels
+	pool       *pool
 }
 
-func New(c *config.ExecHandlerConfig) (*ExecHandler, error) {
+func New(c *config.ExecHandlerConfig, l metrics.Labels) (*ExecHandler, error) {
 	args := []string{}
 	if c.Args != nil {
 		args = append(args, c.Args...)
@@ -51,14 +64,44 @@ func New(c *config.ExecHandlerConfig) (*ExecHandler, error) {
 		}
 	}
 
-	return &ExecHandler{
+	h := &ExecHandler{
 		command:    c.Command,
 		args:       args,
 		env:        env,
 		workingDir: c.WorkingDir,
 		timeout:    timeout,
 		debug:      c.Debug,
-	}, nil
+		labels:     l,
+	}
+
+	if c.Persistent {
+		size := c.PoolSize
+		if size == 0 {
+			size = defaultPoolSize
+		}
+		h.pool = newPool(size, h.startWorker)
+	}
+
+	return h, nil
+}
+
+// Start implements manager.Runnable interface. In persistent mode, it
+// kills the worker processes and waits for their exit when the manager
+// stops.
+func (h *ExecHandler) Start(stop <-chan struct{}) error {
+	<-stop
+
+	if h.pool != nil {
+		h.pool.close()
+	}
+
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable interface.
+// The workers are used on all replicas regardless of leader election.
+func (h *ExecHandler) NeedLeaderElection() bool {
+	return false
 }
 
 func (h *ExecHandler) HandleState(s *state.State) error {
@@ -78,6 +121,7 @@ func (h *ExecHandler) HandleState(s *state.State) error {
 
 	err = json.Unmarshal(out, s)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return err
 	}
 
@@ -99,6 +143,7 @@ func (h *ExecHandler) HandleAdmissionRequest(req admission.Request) (admission.R
 
 	err = json.Unmarshal(out, &res)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return res, err
 	}
 
@@ -120,6 +165,7 @@ func (h *ExecHandler) HandleInjectionRequest(req injection.Request) (injection.R
 
 	err = json.Unmarshal(out, &res)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return res, err
 	}
 
@@ -127,6 +173,10 @@ func (h *ExecHandler) HandleInjectionRequest(req injection.Request) (injection.R
 }
 
 func (h *ExecHandler) run(buf []byte) ([]byte, error) {
+	if h.pool != nil {
+		return h.runWorker(buf)
+	}
+
 	var stdout bytes.Buffer
 
 	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
@@ -143,6 +193,7 @@ func (h *ExecHandler) run(buf []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	start := time.Now()
 	err = cmd.Start()
 	if err != nil {
 		return nil, err
@@ -158,7 +209,16 @@ func (h *ExecHandler) run(buf []byte) ([]byte, error) {
 	}
 
 	err = cmd.Wait()
+	code := strconv.Itoa(cmd.ProcessState.ExitCode())
+	if ctx.Err() == context.DeadlineExceeded {
+		code = metrics.CodeTimeout
+		metrics.IncHandlerTimeout(h.labels)
+	}
+	metrics.ObserveHandler(h.labels, time.Since(start), code)
 	if err != nil {
+		if herr := handler.DecodeError(stdout.Bytes()); herr != nil {
+			return nil, herr
+		}
 		return nil, err
 	}
 
@@ -168,3 +228,57 @@ func (h *ExecHandler) run(buf []byte) ([]byte, error) {
 
 	return stdout.Bytes(), nil
 }
+
+// runWorker sends the request to a worker process in the pool as a line
+// of JSON and reads a line of JSON as the response.
+func (h *ExecHandler) runWorker(buf []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	if h.debug {
+		log.Info("Sending state", "state", string(buf))
+	}
+
+	start := time.Now()
+	out, err := h.pool.call(ctx, buf)
+	if ctx.Err() == context.DeadlineExceeded {
+		metrics.IncHandlerTimeout(h.labels)
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeTimeout)
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		return nil, err
+	}
+
+	if herr := handler.DecodeError(out); herr != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		return nil, herr
+	}
+
+	metrics.ObserveHandler(h.labels, time.Since(start), "0")
+
+	if h.debug {
+		log.Info("Received new state", "state", string(out))
+	}
+
+	return out, nil
+}
+
+// startWorker starts a new worker process of the command.
+func (h *ExecHandler) startWorker() (*worker, error) {
+	cmd := exec.Command(h.command, h.args...)
+	cmd.Env = append(os.Environ(), h.env...)
+	cmd.Dir = h.workingDir
+
+	w, err := startWorker(cmd, func(line string) {
+		log.Info(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Started worker process", "command", h.command, "pid", cmd.Process.Pid)
+
+	return w, nil
+}
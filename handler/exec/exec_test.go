@@ -0,0 +1,184 @@
+package exec
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+func newState() *state.State {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("Test")
+	obj.SetNamespace("default")
+	obj.SetName("test")
+
+	return &state.State{Object: obj}
+}
+
+// metricValue returns the value of the counter, or the number of samples
+// of the histogram, that has the labels.
+func metricValue(name string, labels map[string]string) float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metric:
+		for _, m := range family.GetMetric() {
+			values := map[string]string{}
+			for _, l := range m.GetLabel() {
+				values[l.GetName()] = l.GetValue()
+			}
+			for key, val := range labels {
+				if values[key] != val {
+					continue metric
+				}
+			}
+
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
+
+func TestExecHandlerMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := metrics.Labels{Controller: "exec-metrics", Handler: metrics.HandlerReconciler}
+	labels := map[string]string{"controller": l.Controller, "handler": l.Handler}
+
+	h, err := New(&config.ExecHandlerConfig{Command: "cat"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(metricValue("whitebox_handler_duration_seconds", labels)).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": "0",
+	})).To(Equal(float64(1)))
+
+	// The output that is not JSON
+	h, err = New(&config.ExecHandlerConfig{Command: "echo", Args: []string{"invalid"}}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_decode_errors_total", labels)).To(Equal(float64(1)))
+
+	// The command that exits with nonzero code
+	h, err = New(&config.ExecHandlerConfig{Command: "false"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": "1",
+	})).To(Equal(float64(1)))
+
+	// The command that times out
+	h, err = New(&config.ExecHandlerConfig{Command: "sleep", Args: []string{"10"}, Timeout: "100ms"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_timeouts_total", labels)).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": metrics.CodeTimeout,
+	})).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_duration_seconds", labels)).To(Equal(float64(4)))
+}
+
+func TestExecHandlerMetricsWithPersistent(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := metrics.Labels{Controller: "exec-persistent-metrics", Handler: metrics.HandlerReconciler}
+
+	h, err := New(&config.ExecHandlerConfig{
+		Command:    "/bin/sh",
+		Args:       []string{"-c", `while read line; do sleep 10; done`},
+		Timeout:    "100ms",
+		Persistent: true,
+	}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_timeouts_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler,
+	})).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": metrics.CodeTimeout,
+	})).To(Equal(float64(1)))
+}
+
+// handleConcurrently calls HandleState of the handler from multiple
+// goroutines and returns the first error. Each state must be returned
+// to the goroutine that sent it.
+func handleConcurrently(h *ExecHandler) error {
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := newState()
+			s.Object.SetName(name)
+
+			err := h.HandleState(s)
+			if err == nil && s.Object.GetName() != name {
+				err = fmt.Errorf("unexpected name: %s", s.Object.GetName())
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExecHandlerConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := New(&config.ExecHandlerConfig{Command: "cat"}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(handleConcurrently(h)).NotTo(HaveOccurred())
+
+	c := &config.ExecHandlerConfig{
+		Command:    "/bin/sh",
+		Args:       []string{"-c", `while read line; do echo "$line"; done`},
+		Persistent: true,
+		PoolSize:   3,
+	}
+
+	h, err = New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(handleConcurrently(h)).NotTo(HaveOccurred())
+}
@@ -0,0 +1,249 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+var (
+	errWorkerExited = errors.New("worker process exited")
+	errPoolClosed   = errors.New("worker pool closed")
+)
+
+// pool is a pool of the persistent worker processes. Each worker handles
+// one request at a time, and the requests wait for an idle worker.
+type pool struct {
+	// workers holds the idle workers. A nil worker means a free slot
+	// where a new worker needs to be started.
+	workers chan *worker
+	start   func() (*worker, error)
+
+	// mu protects running and closed. running holds all the workers
+	// that have been started and not killed, including busy ones.
+	mu      sync.Mutex
+	running map[*worker]struct{}
+	closed  bool
+}
+
+func newPool(size int, start func() (*worker, error)) *pool {
+	p := &pool{
+		workers: make(chan *worker, size),
+		start:   start,
+		running: map[*worker]struct{}{},
+	}
+
+	for i := 0; i < size; i++ {
+		p.workers <- nil
+	}
+
+	return p
+}
+
+// call sends the request to an idle worker and returns its response.
+// The worker that exited or timed out is replaced with a new worker on
+// the next call.
+func (p *pool) call(ctx context.Context, buf []byte) ([]byte, error) {
+	var w *worker
+
+	select {
+	case w = <-p.workers:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if w != nil && w.exited() {
+		p.remove(w)
+		w = nil
+	}
+
+	if w == nil {
+		var err error
+
+		w, err = p.startWorker()
+		if err != nil {
+			p.workers <- nil
+			return nil, err
+		}
+	}
+
+	out, err := w.call(ctx, buf)
+	if err != nil {
+		p.remove(w)
+		p.workers <- nil
+		return nil, err
+	}
+
+	p.workers <- w
+
+	return out, nil
+}
+
+// startWorker starts a new worker unless the pool is closed.
+func (p *pool) startWorker() (*worker, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errPoolClosed
+	}
+
+	w, err := p.start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start worker: %v", err)
+	}
+	p.running[w] = struct{}{}
+
+	return w, nil
+}
+
+// remove kills the worker and removes it from the running workers.
+func (p *pool) remove(w *worker) {
+	p.mu.Lock()
+	delete(p.running, w)
+	p.mu.Unlock()
+
+	w.kill()
+}
+
+// close kills all the workers, including the busy ones, and waits for
+// their exit. The calls after close fail without starting a new worker.
+func (p *pool) close() {
+	p.mu.Lock()
+	p.closed = true
+	workers := make([]*worker, 0, len(p.running))
+	for w := range p.running {
+		workers = append(workers, w)
+	}
+	p.running = map[*worker]struct{}{}
+	p.mu.Unlock()
+
+	for _, w := range workers {
+		w.kill()
+	}
+
+	for _, w := range workers {
+		<-w.done
+	}
+}
+
+// worker is a persistent process that reads a request per line from
+// stdin and writes a response per line to stdout.
+type worker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+	reader *bufio.Reader
+	done   chan struct{}
+	once   sync.Once
+}
+
+// startWorker starts the command as a worker. The stderr of the process
+// is passed to the logf function line by line.
+func startWorker(cmd *exec.Cmd, logf func(string)) (*worker, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &worker{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		reader: bufio.NewReader(stdout),
+		done:   make(chan struct{}),
+	}
+
+	// The exit of the process is watched with Process.Wait instead of
+	// cmd.Wait because cmd.Wait closes stdout while the response may
+	// still be read. The stdout is closed by kill.
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logf(scanner.Text())
+		}
+		stderr.Close()
+
+		cmd.Process.Wait()
+		close(w.done)
+	}()
+
+	return w, nil
+}
+
+// call writes the request and reads the response of the worker.
+func (w *worker) call(ctx context.Context, buf []byte) ([]byte, error) {
+	type result struct {
+		line []byte
+		err  error
+	}
+
+	ch := make(chan result, 1)
+
+	go func() {
+		_, err := w.stdin.Write(append(bytes.TrimSpace(buf), '\n'))
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+
+		line, err := w.reader.ReadBytes('\n')
+		if err == io.EOF {
+			err = errWorkerExited
+		}
+		ch <- result{line: bytes.TrimSpace(line), err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.line, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// exited returns whether the process of the worker has exited.
+func (w *worker) exited() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// kill stops the process of the worker and closes its pipes. The stderr
+// is closed as well since the child processes of the worker may keep it
+// open after the worker exits.
+func (w *worker) kill() {
+	w.once.Do(func() {
+		w.stdin.Close()
+		if w.cmd.Process != nil {
+			w.cmd.Process.Kill()
+		}
+		w.stdout.Close()
+		w.stderr.Close()
+	})
+}
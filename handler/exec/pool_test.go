@@ -0,0 +1,181 @@
+package exec
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
+)
+
+func newTestPool(size int, script string) *pool {
+	return newPool(size, func() (*worker, error) {
+		return startWorker(exec.Command("/bin/sh", "-c", script), func(string) {})
+	})
+}
+
+func TestPool(t *testing.T) {
+	RegisterTestingT(t)
+
+	p := newTestPool(2, `while read line; do echo "$line"; done`)
+
+	for i := 0; i < 3; i++ {
+		out, err := p.call(context.TODO(), []byte(`{"test": true}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(`{"test": true}`))
+	}
+}
+
+func TestPoolWithCrash(t *testing.T) {
+	RegisterTestingT(t)
+
+	// The worker exits without the response of the second request.
+	p := newTestPool(1, `read line; echo "$line"; read line; exit 1`)
+
+	out, err := p.call(context.TODO(), []byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(out)).To(Equal(`{}`))
+
+	_, err = p.call(context.TODO(), []byte(`{}`))
+	Expect(err).To(Equal(errWorkerExited))
+
+	// The new worker is started for the next call.
+	out, err = p.call(context.TODO(), []byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(out)).To(Equal(`{}`))
+}
+
+func TestPoolWithExit(t *testing.T) {
+	RegisterTestingT(t)
+
+	// The worker exits right after the response.
+	p := newTestPool(1, `read line; echo "$line"; exit 1`)
+
+	out, err := p.call(context.TODO(), []byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(out)).To(Equal(`{}`))
+
+	// Wait for the exit of the worker.
+	w := <-p.workers
+	Eventually(w.exited).Should(BeTrue())
+	p.workers <- w
+
+	// The exited worker is replaced before the call.
+	out, err = p.call(context.TODO(), []byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(out)).To(Equal(`{}`))
+}
+
+func TestPoolWithTimeout(t *testing.T) {
+	RegisterTestingT(t)
+
+	p := newTestPool(1, `while read line; do sleep 10; done`)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := p.call(ctx, []byte(`{}`))
+	Expect(err).To(Equal(context.DeadlineExceeded))
+
+	// The timed out worker is replaced.
+	w := <-p.workers
+	Expect(w).To(BeNil())
+}
+
+func TestPoolClose(t *testing.T) {
+	RegisterTestingT(t)
+
+	p := newTestPool(2, `while read line; do echo "$line"; done`)
+
+	_, err := p.call(context.TODO(), []byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+
+	// The second worker never responds and is busy on close.
+	p.start = func() (*worker, error) {
+		return startWorker(exec.Command("/bin/sh", "-c", `read line; sleep 10`), func(string) {})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.call(context.TODO(), []byte(`{}`))
+		done <- err
+	}()
+
+	workers := func() []*worker {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		workers := []*worker{}
+		for w := range p.running {
+			workers = append(workers, w)
+		}
+		return workers
+	}
+	Eventually(workers).Should(HaveLen(2))
+
+	running := workers()
+
+	p.close()
+
+	for _, w := range running {
+		Expect(w.exited()).To(BeTrue())
+	}
+	Expect(workers()).To(BeEmpty())
+
+	Eventually(done).Should(Receive(HaveOccurred()))
+
+	_, err = p.call(context.TODO(), []byte(`{}`))
+	Expect(err).To(Equal(errPoolClosed))
+}
+
+func TestExecHandlerWithPersistent(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.ExecHandlerConfig{
+		Command:    "/bin/sh",
+		Args:       []string{"-c", `while read line; do echo '{"error":{"reason":"Invalid","retryable":false}}'; done`},
+		Persistent: true,
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = h.run([]byte(`{}`))
+	Expect(err).To(HaveOccurred())
+
+	herr, ok := err.(*handler.Error)
+	Expect(ok).To(BeTrue())
+	Expect(herr.Reason).To(Equal("Invalid"))
+	Expect(herr.IsRetryable()).To(BeFalse())
+}
+
+func TestExecHandlerStartWithPersistent(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.ExecHandlerConfig{
+		Command:    "/bin/sh",
+		Args:       []string{"-c", `while read line; do echo "$line"; done`},
+		Persistent: true,
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = h.run([]byte(`{}`))
+	Expect(err).NotTo(HaveOccurred())
+
+	stop := make(chan struct{})
+	close(stop)
+
+	err = h.Start(stop)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(h.NeedLeaderElection()).To(BeFalse())
+
+	_, err = h.run([]byte(`{}`))
+	Expect(err).To(Equal(errPoolClosed))
+}
@@ -0,0 +1,287 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/handler/grpc/handlerpb"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+	"github.com/summerwind/whitebox-controller/webhook/injection"
+)
+
+var defaultTimeout = 60 * time.Second
+
+// The prefix of the address to connect to the unix domain socket.
+const unixPrefix = "unix://"
+
+// GRPCHandler is a handler that calls the handler service over gRPC.
+// Concurrent requests are multiplexed over the single client connection;
+// the handler has no other state that changes after New.
+type GRPCHandler struct {
+	conn    *grpc.ClientConn
+	client  handlerpb.HandlerClient
+	timeout time.Duration
+	debug   bool
+	labels  metrics.Labels
+}
+
+func New(c *config.GRPCHandlerConfig, l metrics.Labels) (*GRPCHandler, error) {
+	var (
+		timeout time.Duration
+		err     error
+	)
+
+	if c.Timeout != "" {
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		timeout = defaultTimeout
+	}
+
+	opts := []grpc.DialOption{}
+
+	if c.TLS != nil {
+		tlsConfig := &tls.Config{}
+
+		if c.TLS.KeyFile != "" && c.TLS.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+			if err != nil {
+				return nil, err
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		if c.TLS.CACertFile != "" {
+			caCert, err := ioutil.ReadFile(c.TLS.CACertFile)
+			if err != nil {
+				return nil, err
+			}
+
+			caCertPool := x509.NewCertPool()
+			caCertPool.AppendCertsFromPEM(caCert)
+
+			tlsConfig.RootCAs = caCertPool
+		}
+
+		// The server name is taken from the address if it is empty.
+		tlsConfig.ServerName = c.TLS.ServerName
+
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	addr := c.Address
+	if strings.HasPrefix(addr, unixPrefix) {
+		addr = strings.TrimPrefix(addr, unixPrefix)
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, path string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}))
+	}
+
+	// The connection is established in background and is reused for
+	// all requests.
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GRPCHandler{
+		conn:    conn,
+		client:  handlerpb.NewHandlerClient(conn),
+		timeout: timeout,
+		debug:   c.Debug,
+		labels:  l,
+	}, nil
+}
+
+// Start implements manager.Runnable interface. It closes the connection
+// when the manager stops.
+func (h *GRPCHandler) Start(stop <-chan struct{}) error {
+	<-stop
+	return h.conn.Close()
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable interface.
+// The connection is used on all replicas regardless of leader election.
+func (h *GRPCHandler) NeedLeaderElection() bool {
+	return false
+}
+
+func (h *GRPCHandler) HandleState(s *state.State) error {
+	in, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if h.debug {
+		log("request", string(in))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	res, err := h.client.HandleState(ctx, &handlerpb.StateRequest{State: in})
+	h.observe(start, err)
+	if err != nil {
+		return err
+	}
+
+	if res.Error != nil {
+		return decodeError(res.Error)
+	}
+
+	out := res.State
+	if h.debug {
+		log("response", string(out))
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(out, s)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return err
+	}
+
+	return nil
+}
+
+func (h *GRPCHandler) HandleAdmissionRequest(req admission.Request) (admission.Response, error) {
+	res := admission.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	if h.debug {
+		log("request", string(in))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	pbRes, err := h.client.HandleAdmissionRequest(ctx, &handlerpb.AdmissionRequest{Request: in})
+	h.observe(start, err)
+	if err != nil {
+		return res, err
+	}
+
+	if pbRes.Error != nil {
+		return res, decodeError(pbRes.Error)
+	}
+
+	if h.debug {
+		log("response", string(pbRes.Response))
+	}
+
+	err = json.Unmarshal(pbRes.Response, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (h *GRPCHandler) HandleInjectionRequest(req injection.Request) (injection.Response, error) {
+	res := injection.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	if h.debug {
+		log("request", string(in))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	pbRes, err := h.client.HandleInjectionRequest(ctx, &handlerpb.InjectionRequest{Request: in})
+	h.observe(start, err)
+	if err != nil {
+		return res, err
+	}
+
+	if pbRes.Error != nil {
+		return res, decodeError(pbRes.Error)
+	}
+
+	if h.debug {
+		log("response", string(pbRes.Response))
+	}
+
+	err = json.Unmarshal(pbRes.Response, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+// observe records the metrics of the call. The status code of gRPC is
+// used as the code of the handler except for timeouts.
+func (h *GRPCHandler) observe(start time.Time, err error) {
+	code := status.Code(err)
+	if code == codes.DeadlineExceeded {
+		metrics.IncHandlerTimeout(h.labels)
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeTimeout)
+		return
+	}
+
+	metrics.ObserveHandler(h.labels, time.Since(start), code.String())
+}
+
+// decodeError converts the error in the response to the handler error.
+func decodeError(e *handlerpb.Error) error {
+	herr := &handler.Error{
+		Reason:       e.Reason,
+		Message:      e.Message,
+		RequeueAfter: int(e.RequeueAfter),
+	}
+
+	if e.Retryable != nil {
+		retryable := e.Retryable.Value
+		herr.Retryable = &retryable
+	}
+
+	if herr.Reason == "" {
+		return fmt.Errorf("invalid error: reason must be specified: %s", e.Message)
+	}
+
+	return herr
+}
+
+func log(stream, msg string) {
+	fmt.Fprintf(os.Stderr, "[grpc] %s: %s\n", stream, msg)
+}
@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/handler/grpc/handlerpb"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+type testServer struct{}
+
+func (s *testServer) HandleState(ctx context.Context, req *handlerpb.StateRequest) (*handlerpb.StateResponse, error) {
+	st := state.State{}
+
+	err := json.Unmarshal(req.State, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.Object.GetName() == "fail" {
+		return &handlerpb.StateResponse{
+			Error: &handlerpb.Error{
+				Reason:    "InvalidSpec",
+				Message:   "spec is invalid",
+				Retryable: &wrappers.BoolValue{Value: false},
+			},
+		}, nil
+	}
+
+	st.Object.SetLabels(map[string]string{"handled": "true"})
+
+	out, err := json.Marshal(&st)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlerpb.StateResponse{State: out}, nil
+}
+
+func (s *testServer) HandleAdmissionRequest(ctx context.Context, req *handlerpb.AdmissionRequest) (*handlerpb.AdmissionResponse, error) {
+	return &handlerpb.AdmissionResponse{Response: []byte(`{"allowed":true}`)}, nil
+}
+
+func (s *testServer) HandleInjectionRequest(ctx context.Context, req *handlerpb.InjectionRequest) (*handlerpb.InjectionResponse, error) {
+	return &handlerpb.InjectionResponse{Response: []byte(`{"object":null}`)}, nil
+}
+
+func startServer(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "whitebox-grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "handler.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	handlerpb.RegisterHandlerServer(server, &testServer{})
+	go server.Serve(l)
+
+	return unixPrefix + path, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func newObject(name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("Test")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+
+	return obj
+}
+
+func TestGRPCHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	addr, stop := startServer(t)
+	defer stop()
+
+	h, err := New(&config.GRPCHandlerConfig{Address: addr}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	s := &state.State{Object: newObject("test")}
+
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object.GetLabels()["handled"]).To(Equal("true"))
+
+	s = &state.State{Object: newObject("fail")}
+
+	err = h.HandleState(s)
+	Expect(err).To(HaveOccurred())
+
+	herr, ok := err.(*handler.Error)
+	Expect(ok).To(BeTrue())
+	Expect(herr.Reason).To(Equal("InvalidSpec"))
+	Expect(herr.IsRetryable()).To(BeFalse())
+
+	ares, err := h.HandleAdmissionRequest(admission.Request{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ares.Allowed).To(BeTrue())
+}
+
+func TestGRPCHandlerConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	addr, stop := startServer(t)
+	defer stop()
+
+	h, err := New(&config.GRPCHandlerConfig{Address: addr}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := &state.State{Object: newObject(name)}
+
+			err := h.HandleState(s)
+			if err == nil && (s.Object.GetName() != name || s.Object.GetLabels()["handled"] != "true") {
+				err = fmt.Errorf("unexpected object: %v", s.Object)
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func TestGRPCHandlerStart(t *testing.T) {
+	RegisterTestingT(t)
+
+	addr, stop := startServer(t)
+	defer stop()
+
+	h, err := New(&config.GRPCHandlerConfig{Address: addr}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(&state.State{Object: newObject("test")})
+	Expect(err).NotTo(HaveOccurred())
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+
+	err = h.Start(stopCh)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(h.NeedLeaderElection()).To(BeFalse())
+
+	err = h.HandleState(&state.State{Object: newObject("test")})
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,11 @@
+// Package handlerpb contains the gRPC service definition of the handler.
+//
+// handler.pb.go is generated from handler.proto by 'go generate' with
+// protoc 3.13 or earlier and protoc-gen-go v1.3.2 of
+// github.com/golang/protobuf. Other versions generate different code:
+// the later versions of protoc-gen-go generate the client for a newer
+// gRPC API, and the later versions of protoc import the wrapper types
+// from google.golang.org/protobuf.
+package handlerpb
+
+//go:generate protoc --go_out=plugins=grpc:. handler.proto
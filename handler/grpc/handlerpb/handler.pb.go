@@ -0,0 +1,558 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: handler.proto
+
+package handlerpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type StateRequest struct {
+	// JSON encoded state.
+	State                []byte   `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateRequest) Reset()         { *m = StateRequest{} }
+func (m *StateRequest) String() string { return proto.CompactTextString(m) }
+func (*StateRequest) ProtoMessage()    {}
+func (*StateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{0}
+}
+
+func (m *StateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateRequest.Unmarshal(m, b)
+}
+func (m *StateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateRequest.Marshal(b, m, deterministic)
+}
+func (m *StateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateRequest.Merge(m, src)
+}
+func (m *StateRequest) XXX_Size() int {
+	return xxx_messageInfo_StateRequest.Size(m)
+}
+func (m *StateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateRequest proto.InternalMessageInfo
+
+func (m *StateRequest) GetState() []byte {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+type StateResponse struct {
+	// JSON encoded new state. If it is empty, the state is not changed.
+	State []byte `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	// The error of the handler.
+	Error                *Error   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateResponse) Reset()         { *m = StateResponse{} }
+func (m *StateResponse) String() string { return proto.CompactTextString(m) }
+func (*StateResponse) ProtoMessage()    {}
+func (*StateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{1}
+}
+
+func (m *StateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateResponse.Unmarshal(m, b)
+}
+func (m *StateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateResponse.Marshal(b, m, deterministic)
+}
+func (m *StateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateResponse.Merge(m, src)
+}
+func (m *StateResponse) XXX_Size() int {
+	return xxx_messageInfo_StateResponse.Size(m)
+}
+func (m *StateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateResponse proto.InternalMessageInfo
+
+func (m *StateResponse) GetState() []byte {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+func (m *StateResponse) GetError() *Error {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+type AdmissionRequest struct {
+	// JSON encoded admission request.
+	Request              []byte   `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdmissionRequest) Reset()         { *m = AdmissionRequest{} }
+func (m *AdmissionRequest) String() string { return proto.CompactTextString(m) }
+func (*AdmissionRequest) ProtoMessage()    {}
+func (*AdmissionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{2}
+}
+
+func (m *AdmissionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdmissionRequest.Unmarshal(m, b)
+}
+func (m *AdmissionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdmissionRequest.Marshal(b, m, deterministic)
+}
+func (m *AdmissionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdmissionRequest.Merge(m, src)
+}
+func (m *AdmissionRequest) XXX_Size() int {
+	return xxx_messageInfo_AdmissionRequest.Size(m)
+}
+func (m *AdmissionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdmissionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdmissionRequest proto.InternalMessageInfo
+
+func (m *AdmissionRequest) GetRequest() []byte {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+type AdmissionResponse struct {
+	// JSON encoded admission response.
+	Response []byte `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	// The error of the handler.
+	Error                *Error   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdmissionResponse) Reset()         { *m = AdmissionResponse{} }
+func (m *AdmissionResponse) String() string { return proto.CompactTextString(m) }
+func (*AdmissionResponse) ProtoMessage()    {}
+func (*AdmissionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{3}
+}
+
+func (m *AdmissionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdmissionResponse.Unmarshal(m, b)
+}
+func (m *AdmissionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdmissionResponse.Marshal(b, m, deterministic)
+}
+func (m *AdmissionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdmissionResponse.Merge(m, src)
+}
+func (m *AdmissionResponse) XXX_Size() int {
+	return xxx_messageInfo_AdmissionResponse.Size(m)
+}
+func (m *AdmissionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdmissionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdmissionResponse proto.InternalMessageInfo
+
+func (m *AdmissionResponse) GetResponse() []byte {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *AdmissionResponse) GetError() *Error {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+type InjectionRequest struct {
+	// JSON encoded injection request.
+	Request              []byte   `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InjectionRequest) Reset()         { *m = InjectionRequest{} }
+func (m *InjectionRequest) String() string { return proto.CompactTextString(m) }
+func (*InjectionRequest) ProtoMessage()    {}
+func (*InjectionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{4}
+}
+
+func (m *InjectionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InjectionRequest.Unmarshal(m, b)
+}
+func (m *InjectionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InjectionRequest.Marshal(b, m, deterministic)
+}
+func (m *InjectionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InjectionRequest.Merge(m, src)
+}
+func (m *InjectionRequest) XXX_Size() int {
+	return xxx_messageInfo_InjectionRequest.Size(m)
+}
+func (m *InjectionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InjectionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InjectionRequest proto.InternalMessageInfo
+
+func (m *InjectionRequest) GetRequest() []byte {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+type InjectionResponse struct {
+	// JSON encoded injection response.
+	Response []byte `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	// The error of the handler.
+	Error                *Error   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InjectionResponse) Reset()         { *m = InjectionResponse{} }
+func (m *InjectionResponse) String() string { return proto.CompactTextString(m) }
+func (*InjectionResponse) ProtoMessage()    {}
+func (*InjectionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{5}
+}
+
+func (m *InjectionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InjectionResponse.Unmarshal(m, b)
+}
+func (m *InjectionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InjectionResponse.Marshal(b, m, deterministic)
+}
+func (m *InjectionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InjectionResponse.Merge(m, src)
+}
+func (m *InjectionResponse) XXX_Size() int {
+	return xxx_messageInfo_InjectionResponse.Size(m)
+}
+func (m *InjectionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InjectionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InjectionResponse proto.InternalMessageInfo
+
+func (m *InjectionResponse) GetResponse() []byte {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *InjectionResponse) GetError() *Error {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+// Error is the structured error of the handler. It has the same fields
+// as the error envelope of the exec and http handlers.
+type Error struct {
+	Reason               string              `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message              string              `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Retryable            *wrappers.BoolValue `protobuf:"bytes,3,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	RequeueAfter         int32               `protobuf:"varint,4,opt,name=requeue_after,json=requeueAfter,proto3" json:"requeue_after,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *Error) Reset()         { *m = Error{} }
+func (m *Error) String() string { return proto.CompactTextString(m) }
+func (*Error) ProtoMessage()    {}
+func (*Error) Descriptor() ([]byte, []int) {
+	return fileDescriptor_515968b8e1a22554, []int{6}
+}
+
+func (m *Error) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Error.Unmarshal(m, b)
+}
+func (m *Error) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Error.Marshal(b, m, deterministic)
+}
+func (m *Error) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Error.Merge(m, src)
+}
+func (m *Error) XXX_Size() int {
+	return xxx_messageInfo_Error.Size(m)
+}
+func (m *Error) XXX_DiscardUnknown() {
+	xxx_messageInfo_Error.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Error proto.InternalMessageInfo
+
+func (m *Error) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *Error) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *Error) GetRetryable() *wrappers.BoolValue {
+	if m != nil {
+		return m.Retryable
+	}
+	return nil
+}
+
+func (m *Error) GetRequeueAfter() int32 {
+	if m != nil {
+		return m.RequeueAfter
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*StateRequest)(nil), "whitebox.handler.StateRequest")
+	proto.RegisterType((*StateResponse)(nil), "whitebox.handler.StateResponse")
+	proto.RegisterType((*AdmissionRequest)(nil), "whitebox.handler.AdmissionRequest")
+	proto.RegisterType((*AdmissionResponse)(nil), "whitebox.handler.AdmissionResponse")
+	proto.RegisterType((*InjectionRequest)(nil), "whitebox.handler.InjectionRequest")
+	proto.RegisterType((*InjectionResponse)(nil), "whitebox.handler.InjectionResponse")
+	proto.RegisterType((*Error)(nil), "whitebox.handler.Error")
+}
+
+func init() { proto.RegisterFile("handler.proto", fileDescriptor_515968b8e1a22554) }
+
+var fileDescriptor_515968b8e1a22554 = []byte{
+	// 371 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0xcf, 0x4e, 0xe3, 0x30,
+	0x10, 0xc6, 0x95, 0xee, 0xa6, 0xdd, 0x4c, 0x5b, 0xa9, 0x6b, 0xad, 0xba, 0x51, 0x0e, 0xdd, 0x2a,
+	0xdd, 0x43, 0x0f, 0x90, 0x4a, 0xe5, 0xc2, 0xb5, 0x95, 0x90, 0xe0, 0xc2, 0x21, 0x20, 0x0e, 0x1c,
+	0x40, 0x0e, 0x9d, 0xfe, 0x41, 0x69, 0x1c, 0x6c, 0x47, 0x85, 0x37, 0xe1, 0xc6, 0xab, 0xa2, 0xd8,
+	0x0e, 0x54, 0x49, 0xa9, 0x10, 0xe2, 0x36, 0xdf, 0xcc, 0xcf, 0x33, 0x9f, 0xed, 0x81, 0xf6, 0x92,
+	0x26, 0xb3, 0x18, 0x79, 0x90, 0x72, 0x26, 0x19, 0xe9, 0x6c, 0x96, 0x2b, 0x89, 0x11, 0x7b, 0x0c,
+	0x4c, 0xde, 0xeb, 0x2d, 0x18, 0x5b, 0xc4, 0x38, 0x52, 0xf5, 0x28, 0x9b, 0x8f, 0x36, 0x9c, 0xa6,
+	0x29, 0x72, 0xa1, 0x4f, 0xf8, 0xff, 0xa1, 0x75, 0x21, 0xa9, 0xc4, 0x10, 0x1f, 0x32, 0x14, 0x92,
+	0xfc, 0x01, 0x5b, 0xe4, 0xda, 0xb5, 0xfa, 0xd6, 0xb0, 0x15, 0x6a, 0xe1, 0x5f, 0x42, 0xdb, 0x50,
+	0x22, 0x65, 0x89, 0xc0, 0xdd, 0x18, 0x39, 0x04, 0x1b, 0x39, 0x67, 0xdc, 0xad, 0xf5, 0xad, 0x61,
+	0x73, 0xfc, 0x37, 0x28, 0xdb, 0x09, 0x4e, 0xf2, 0x72, 0xa8, 0x29, 0xff, 0x00, 0x3a, 0x93, 0xd9,
+	0x7a, 0x25, 0xc4, 0x8a, 0x25, 0xc5, 0x7c, 0x17, 0x1a, 0x5c, 0x87, 0xa6, 0x75, 0x21, 0xfd, 0x1b,
+	0xf8, 0xbd, 0x45, 0x1b, 0x1f, 0x1e, 0xfc, 0xe2, 0x26, 0x36, 0xfc, 0x9b, 0xfe, 0x82, 0x9b, 0xb3,
+	0xe4, 0x1e, 0xef, 0xe4, 0x67, 0xdd, 0x6c, 0xd1, 0xdf, 0xef, 0xe6, 0xd9, 0x02, 0x5b, 0x25, 0x48,
+	0x17, 0xea, 0x1c, 0xa9, 0x60, 0x89, 0x6a, 0xe9, 0x84, 0x46, 0xe5, 0xde, 0xd6, 0x28, 0x04, 0x5d,
+	0xa0, 0x6a, 0xe9, 0x84, 0x85, 0x24, 0xc7, 0xe0, 0x70, 0x94, 0xfc, 0x89, 0x46, 0x31, 0xba, 0x3f,
+	0xd4, 0x38, 0x2f, 0xd0, 0x7b, 0x10, 0x14, 0x7b, 0x10, 0x4c, 0x19, 0x8b, 0xaf, 0x68, 0x9c, 0x61,
+	0xf8, 0x0e, 0x93, 0x01, 0xb4, 0xd5, 0x05, 0x33, 0xbc, 0xa5, 0x73, 0x89, 0xdc, 0xfd, 0xd9, 0xb7,
+	0x86, 0x76, 0xd8, 0x32, 0xc9, 0x49, 0x9e, 0x1b, 0xbf, 0xd4, 0xa0, 0x71, 0xaa, 0x3d, 0x93, 0x73,
+	0x68, 0xea, 0x50, 0xad, 0x07, 0xe9, 0x55, 0x6f, 0xb5, 0xbd, 0x5d, 0xde, 0xbf, 0x0f, 0xeb, 0xe6,
+	0x95, 0x28, 0x74, 0x75, 0xbf, 0xca, 0x62, 0xf8, 0xd5, 0xa3, 0x65, 0xc6, 0x1b, 0xec, 0x65, 0xca,
+	0x23, 0x2a, 0xbf, 0xbd, 0x63, 0x44, 0x99, 0xf1, 0x06, 0x7b, 0x19, 0x3d, 0x62, 0xda, 0xbc, 0x76,
+	0x4c, 0x31, 0x8d, 0xa2, 0xba, 0x7a, 0xf2, 0xa3, 0xd7, 0x01, 0x00, 0xa5, 0x5a, 0x33, 0x16, 0xab,
+	0x03, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// HandlerClient is the client API for Handler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HandlerClient interface {
+	// HandleState reconciles the state of the resource.
+	HandleState(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error)
+	// HandleAdmissionRequest handles the admission request of the
+	// validator and the mutator.
+	HandleAdmissionRequest(ctx context.Context, in *AdmissionRequest, opts ...grpc.CallOption) (*AdmissionResponse, error)
+	// HandleInjectionRequest handles the injection request of the injector.
+	HandleInjectionRequest(ctx context.Context, in *InjectionRequest, opts ...grpc.CallOption) (*InjectionResponse, error)
+}
+
+type handlerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHandlerClient(cc *grpc.ClientConn) HandlerClient {
+	return &handlerClient{cc}
+}
+
+func (c *handlerClient) HandleState(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error) {
+	out := new(StateResponse)
+	err := c.cc.Invoke(ctx, "/whitebox.handler.Handler/HandleState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerClient) HandleAdmissionRequest(ctx context.Context, in *AdmissionRequest, opts ...grpc.CallOption) (*AdmissionResponse, error) {
+	out := new(AdmissionResponse)
+	err := c.cc.Invoke(ctx, "/whitebox.handler.Handler/HandleAdmissionRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerClient) HandleInjectionRequest(ctx context.Context, in *InjectionRequest, opts ...grpc.CallOption) (*InjectionResponse, error) {
+	out := new(InjectionResponse)
+	err := c.cc.Invoke(ctx, "/whitebox.handler.Handler/HandleInjectionRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HandlerServer is the server API for Handler service.
+type HandlerServer interface {
+	// HandleState reconciles the state of the resource.
+	HandleState(context.Context, *StateRequest) (*StateResponse, error)
+	// HandleAdmissionRequest handles the admission request of the
+	// validator and the mutator.
+	HandleAdmissionRequest(context.Context, *AdmissionRequest) (*AdmissionResponse, error)
+	// HandleInjectionRequest handles the injection request of the injector.
+	HandleInjectionRequest(context.Context, *InjectionRequest) (*InjectionResponse, error)
+}
+
+// UnimplementedHandlerServer can be embedded to have forward compatible implementations.
+type UnimplementedHandlerServer struct {
+}
+
+func (*UnimplementedHandlerServer) HandleState(ctx context.Context, req *StateRequest) (*StateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleState not implemented")
+}
+func (*UnimplementedHandlerServer) HandleAdmissionRequest(ctx context.Context, req *AdmissionRequest) (*AdmissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleAdmissionRequest not implemented")
+}
+func (*UnimplementedHandlerServer) HandleInjectionRequest(ctx context.Context, req *InjectionRequest) (*InjectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleInjectionRequest not implemented")
+}
+
+func RegisterHandlerServer(s *grpc.Server, srv HandlerServer) {
+	s.RegisterService(&_Handler_serviceDesc, srv)
+}
+
+func _Handler_HandleState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerServer).HandleState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/whitebox.handler.Handler/HandleState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerServer).HandleState(ctx, req.(*StateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Handler_HandleAdmissionRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdmissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerServer).HandleAdmissionRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/whitebox.handler.Handler/HandleAdmissionRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerServer).HandleAdmissionRequest(ctx, req.(*AdmissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Handler_HandleInjectionRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InjectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerServer).HandleInjectionRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/whitebox.handler.Handler/HandleInjectionRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerServer).HandleInjectionRequest(ctx, req.(*InjectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Handler_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "whitebox.handler.Handler",
+	HandlerType: (*HandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "HandleState",
+			Handler:    _Handler_HandleState_Handler,
+		},
+		{
+			MethodName: "HandleAdmissionRequest",
+			Handler:    _Handler_HandleAdmissionRequest_Handler,
+		},
+		{
+			MethodName: "HandleInjectionRequest",
+			Handler:    _Handler_HandleInjectionRequest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "handler.proto",
+}
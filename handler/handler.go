@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/summerwind/whitebox-controller/reconciler/state"
@@ -22,3 +25,45 @@ type AdmissionRequestHandler interface {
 type InjectionRequestHandler interface {
 	HandleInjectionRequest(injection.Request) (injection.Response, error)
 }
+
+// Error is a structured error returned by handler. Handler returns it
+// in the envelope like '{"error": {...}}' with non-zero exit code or
+// non-200 status.
+type Error struct {
+	Reason       string `json:"reason"`
+	Message      string `json:"message,omitempty"`
+	Retryable    *bool  `json:"retryable,omitempty"`
+	RequeueAfter int    `json:"requeueAfter,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// IsRetryable returns whether the handler should be run again.
+// The error is retryable unless it is explicitly set to false.
+func (e *Error) IsRetryable() bool {
+	return e.Retryable == nil || *e.Retryable
+}
+
+// DecodeError decodes the error envelope in the output of handler.
+// If the output is not an error envelope, nil is returned.
+func DecodeError(buf []byte) *Error {
+	envelope := struct {
+		Error *Error `json:"error"`
+	}{}
+
+	err := json.Unmarshal(buf, &envelope)
+	if err != nil {
+		return nil
+	}
+
+	if envelope.Error == nil || envelope.Error.Reason == "" {
+		return nil
+	}
+
+	return envelope.Error
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDecodeError(t *testing.T) {
+	RegisterTestingT(t)
+
+	herr := DecodeError([]byte(`{"error":{"reason":"InvalidSpec","message":"replicas must be positive","retryable":false,"requeueAfter":30}}`))
+	Expect(herr).NotTo(BeNil())
+	Expect(herr.Reason).To(Equal("InvalidSpec"))
+	Expect(herr.Message).To(Equal("replicas must be positive"))
+	Expect(herr.IsRetryable()).To(BeFalse())
+	Expect(herr.RequeueAfter).To(Equal(30))
+	Expect(herr.Error()).To(Equal("InvalidSpec: replicas must be positive"))
+
+	herr = DecodeError([]byte(`{"error":{"reason":"Unavailable"}}`))
+	Expect(herr).NotTo(BeNil())
+	Expect(herr.IsRetryable()).To(BeTrue())
+
+	Expect(DecodeError([]byte(`{"error":{"message":"no reason"}}`))).To(BeNil())
+	Expect(DecodeError([]byte(`{"object":{}}`))).To(BeNil())
+	Expect(DecodeError([]byte(`invalid`))).To(BeNil())
+	Expect(DecodeError([]byte{})).To(BeNil())
+}
@@ -7,26 +7,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
 	"github.com/summerwind/whitebox-controller/reconciler/state"
 	"github.com/summerwind/whitebox-controller/webhook/injection"
 )
 
 var defaultTimeout = 60 * time.Second
 
+// HTTPHandler is a handler that sends each request to a URL.
+// The http.Client is shared by concurrent requests, which it supports
+// by pooling the connections in its transport.
 type HTTPHandler struct {
 	client *http.Client
 	url    string
 	debug  bool
+	labels metrics.Labels
 }
 
-func New(c *config.HTTPHandlerConfig) (*HTTPHandler, error) {
+func New(c *config.HTTPHandlerConfig, l metrics.Labels) (*HTTPHandler, error) {
 	var (
 		timeout time.Duration
 		err     error
@@ -65,6 +73,8 @@ func New(c *config.HTTPHandlerConfig) (*HTTPHandler, error) {
 
 			tlsConfig.RootCAs = caCertPool
 		}
+
+		tlsConfig.ServerName = c.TLS.ServerName
 	}
 
 	client := &http.Client{
@@ -78,6 +88,7 @@ func New(c *config.HTTPHandlerConfig) (*HTTPHandler, error) {
 		client: client,
 		url:    c.URL,
 		debug:  c.Debug,
+		labels: l,
 	}, nil
 }
 
@@ -98,6 +109,7 @@ func (h *HTTPHandler) HandleState(s *state.State) error {
 
 	err = json.Unmarshal(out, s)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return err
 	}
 
@@ -119,6 +131,7 @@ func (h *HTTPHandler) HandleAdmissionRequest(req admission.Request) (admission.R
 
 	err = json.Unmarshal(out, &res)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return res, err
 	}
 
@@ -140,6 +153,7 @@ func (h *HTTPHandler) HandleInjectionRequest(req injection.Request) (injection.R
 
 	err = json.Unmarshal(out, &res)
 	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
 		return res, err
 	}
 
@@ -159,21 +173,33 @@ func (h *HTTPHandler) run(buf []byte) ([]byte, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
 	res, err := h.client.Do(req)
 	if err != nil {
+		code := metrics.CodeError
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			code = metrics.CodeTimeout
+			metrics.IncHandlerTimeout(h.labels)
+		}
+		metrics.ObserveHandler(h.labels, time.Since(start), code)
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid status: %s", res.Status)
-	}
+	metrics.ObserveHandler(h.labels, time.Since(start), strconv.Itoa(res.StatusCode))
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	if res.StatusCode != http.StatusOK {
+		if herr := handler.DecodeError(resBody); herr != nil {
+			return nil, herr
+		}
+		return nil, fmt.Errorf("invalid status: %s", res.Status)
+	}
+
 	if h.debug {
 		log("response", string(resBody))
 	}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+func newState() *state.State {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("Test")
+	obj.SetNamespace("default")
+	obj.SetName("test")
+
+	return &state.State{Object: obj}
+}
+
+// metricValue returns the value of the counter, or the number of samples
+// of the histogram, that has the labels.
+func metricValue(name string, labels map[string]string) float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metric:
+		for _, m := range family.GetMetric() {
+			values := map[string]string{}
+			for _, l := range m.GetLabel() {
+				values[l.GetName()] = l.GetValue()
+			}
+			for key, val := range labels {
+				if values[key] != val {
+					continue metric
+				}
+			}
+
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
+
+func TestHTTPHandlerMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/invalid":
+			fmt.Fprint(w, "invalid")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(time.Second)
+		default:
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	l := metrics.Labels{Controller: "http-metrics", Handler: metrics.HandlerReconciler}
+	labels := map[string]string{"controller": l.Controller, "handler": l.Handler}
+
+	h, err := New(&config.HTTPHandlerConfig{URL: server.URL}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(metricValue("whitebox_handler_duration_seconds", labels)).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": "200",
+	})).To(Equal(float64(1)))
+
+	// The response that is not JSON
+	h, err = New(&config.HTTPHandlerConfig{URL: server.URL + "/invalid"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_decode_errors_total", labels)).To(Equal(float64(1)))
+
+	// The response with error status
+	h, err = New(&config.HTTPHandlerConfig{URL: server.URL + "/error"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": "500",
+	})).To(Equal(float64(1)))
+
+	// The request that times out
+	h, err = New(&config.HTTPHandlerConfig{URL: server.URL + "/slow", Timeout: "100ms"}, l)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(metricValue("whitebox_handler_timeouts_total", labels)).To(Equal(float64(1)))
+	Expect(metricValue("whitebox_handler_results_total", map[string]string{
+		"controller": l.Controller, "handler": l.Handler, "code": metrics.CodeTimeout,
+	})).To(Equal(float64(1)))
+}
+
+func TestHTTPHandlerConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	h, err := New(&config.HTTPHandlerConfig{URL: server.URL}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := newState()
+			s.Object.SetName(name)
+
+			err := h.HandleState(s)
+			if err == nil && s.Object.GetName() != name {
+				err = fmt.Errorf("unexpected name: %s", s.Object.GetName())
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
@@ -0,0 +1,409 @@
+package script
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"go.starlark.net/starlark"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+	"github.com/summerwind/whitebox-controller/webhook/injection"
+)
+
+var log = logf.Log.WithName("handler")
+
+var defaultTimeout = 60 * time.Second
+
+// The name of the function called by the handler.
+const entrypoint = "handle"
+
+// The key of thread local to store the events of the script.
+const eventsKey = "events"
+
+// ScriptHandler is a handler that runs a Starlark script in process.
+// The script must define 'handle' function that receives the same
+// payload as the exec handler as a dict and returns the result.
+// The globals are frozen after the script is loaded, so a request
+// cannot change what another request sees. The step budget and the
+// events are kept in the thread created for each request.
+type ScriptHandler struct {
+	fn       starlark.Callable
+	maxSteps uint64
+	timeout  time.Duration
+	debug    bool
+	labels   metrics.Labels
+}
+
+func New(c *config.ScriptHandlerConfig, l metrics.Labels) (*ScriptHandler, error) {
+	var (
+		timeout time.Duration
+		err     error
+	)
+
+	if c.Timeout != "" {
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		timeout = defaultTimeout
+	}
+
+	filename := c.File
+	src := []byte(c.Source)
+	if filename != "" {
+		src, err = ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		filename = "script"
+	}
+
+	thread := &starlark.Thread{Name: "init", Print: printLog}
+	globals, err := starlark.ExecFile(thread, filename, src, builtins())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script: %v", err)
+	}
+	globals.Freeze()
+
+	fn, ok := globals[entrypoint].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("function '%s' must be defined in script", entrypoint)
+	}
+
+	return &ScriptHandler{
+		fn:       fn,
+		maxSteps: c.MaxSteps,
+		timeout:  timeout,
+		debug:    c.Debug,
+		labels:   l,
+	}, nil
+}
+
+func (h *ScriptHandler) HandleState(s *state.State) error {
+	in, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	events := []state.Event{}
+
+	out, err := h.run(in, &events)
+	if err != nil {
+		return err
+	}
+
+	if len(out) != 0 {
+		err = json.Unmarshal(out, s)
+		if err != nil {
+			metrics.IncHandlerDecodeError(h.labels)
+			return err
+		}
+	}
+
+	s.Events = append(s.Events, events...)
+
+	return nil
+}
+
+func (h *ScriptHandler) HandleAdmissionRequest(req admission.Request) (admission.Response, error) {
+	res := admission.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	out, err := h.run(in, nil)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (h *ScriptHandler) HandleInjectionRequest(req injection.Request) (injection.Response, error) {
+	res := injection.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	out, err := h.run(in, nil)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+// run calls the function of the script with the payload and returns
+// the result encoded in JSON. If events is not nil, the events emitted
+// by the script are appended to it.
+func (h *ScriptHandler) run(buf []byte, events *[]state.Event) ([]byte, error) {
+	if h.debug {
+		log.Info("Sending state", "state", string(buf))
+	}
+
+	arg, err := decodeValue(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &starlark.Thread{Name: entrypoint, Print: printLog}
+	thread.SetMaxExecutionSteps(h.maxSteps)
+	if events != nil {
+		thread.SetLocal(eventsKey, events)
+	}
+
+	var timedOut int32
+	timer := time.AfterFunc(h.timeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		thread.Cancel("timeout")
+	})
+
+	start := time.Now()
+	v, err := starlark.Call(thread, h.fn, starlark.Tuple{arg}, nil)
+	timer.Stop()
+	if err != nil && atomic.LoadInt32(&timedOut) == 1 {
+		metrics.IncHandlerTimeout(h.labels)
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeTimeout)
+		return nil, err
+	}
+	if err != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, errors.New(evalErr.Backtrace())
+		}
+		return nil, err
+	}
+
+	out, err := encodeValue(v)
+	if err != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		metrics.IncHandlerDecodeError(h.labels)
+		return nil, err
+	}
+
+	if herr := handler.DecodeError(out); herr != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		return nil, herr
+	}
+
+	metrics.ObserveHandler(h.labels, time.Since(start), "0")
+
+	if h.debug {
+		log.Info("Received new state", "state", string(out))
+	}
+
+	return out, nil
+}
+
+// builtins returns the helper functions available in the script.
+func builtins() starlark.StringDict {
+	return starlark.StringDict{
+		"log":   starlark.NewBuiltin("log", logBuiltin),
+		"event": starlark.NewBuiltin("event", eventBuiltin),
+	}
+}
+
+// logBuiltin implements 'log(msg, **kwargs)' that writes a message with
+// key-value pairs to the log of the controller.
+func logBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var msg string
+
+	err := starlark.UnpackPositionalArgs(b.Name(), args, nil, 1, &msg)
+	if err != nil {
+		return nil, err
+	}
+
+	kv := []interface{}{}
+	for _, pair := range kwargs {
+		val, err := toGo(pair[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", b.Name(), err)
+		}
+		kv = append(kv, string(pair[0].(starlark.String)), val)
+	}
+
+	log.Info(msg, kv...)
+
+	return starlark.None, nil
+}
+
+// eventBuiltin implements 'event(type, reason, message)' that records
+// an event for the resource. It is available only in the reconciler
+// and the finalizer.
+func eventBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var e state.Event
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "type", &e.Type, "reason", &e.Reason, "message?", &e.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+
+	events, ok := thread.Local(eventsKey).(*[]state.Event)
+	if !ok {
+		return nil, fmt.Errorf("%s: events are not available in this handler", b.Name())
+	}
+	*events = append(*events, e)
+
+	return starlark.None, nil
+}
+
+// printLog writes the output of 'print' to the log of the controller.
+func printLog(thread *starlark.Thread, msg string) {
+	log.Info(msg)
+}
+
+// decodeValue decodes the JSON to Starlark value.
+func decodeValue(buf []byte) (starlark.Value, error) {
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.UseNumber()
+
+	err := d.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStarlark(v)
+}
+
+// encodeValue encodes the Starlark value to JSON. None is encoded to
+// empty so that the result is handled as no change.
+func encodeValue(v starlark.Value) ([]byte, error) {
+	if v == starlark.None {
+		return nil, nil
+	}
+
+	val, err := toGo(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result: %v", err)
+	}
+
+	return json.Marshal(val)
+}
+
+// toStarlark converts the value decoded from JSON to Starlark value.
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return starlark.MakeInt64(i), nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return starlark.Float(f), nil
+	case []interface{}:
+		list := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, sv)
+		}
+		return starlark.NewList(list), nil
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(val))
+		for key, item := range val {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			err = dict.SetKey(starlark.String(key), sv)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type: %T", v)
+}
+
+// toGo converts the Starlark value to the value that can be encoded
+// to JSON.
+func toGo(v starlark.Value) (interface{}, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		i, ok := val.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer out of range: %s", val)
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(val), nil
+	case starlark.Indexable:
+		list := make([]interface{}, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			item, err := toGo(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case *starlark.Dict:
+		m := make(map[string]interface{}, val.Len())
+		for _, item := range val.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key must be string: %s", item[0].Type())
+			}
+			gv, err := toGo(item[1])
+			if err != nil {
+				return nil, err
+			}
+			m[string(key)] = gv
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", v.Type())
+}
@@ -0,0 +1,199 @@
+package script
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+func newState() *state.State {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("Test")
+	obj.SetNamespace("default")
+	obj.SetName("test")
+
+	unstructured.SetNestedField(obj.Object, int64(3), "spec", "replicas")
+
+	return &state.State{Object: obj}
+}
+
+func newHandler(src string) (*ScriptHandler, error) {
+	c := &config.ScriptHandlerConfig{
+		Source:   src,
+		MaxSteps: 10000,
+		Timeout:  "1s",
+	}
+
+	return New(c, metrics.Labels{})
+}
+
+func TestHandleState(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(state):
+    obj = state["object"]
+    obj["status"] = {"replicas": obj["spec"]["replicas"], "ready": True}
+    log("reconciled", name=obj["metadata"]["name"])
+    event("Normal", "Reconciled", "reconciled")
+    return state
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	replicas, _, _ := unstructured.NestedInt64(s.Object.Object, "status", "replicas")
+	Expect(replicas).To(Equal(int64(3)))
+	ready, _, _ := unstructured.NestedBool(s.Object.Object, "status", "ready")
+	Expect(ready).To(BeTrue())
+
+	Expect(s.Events).To(Equal([]state.Event{
+		{Type: "Normal", Reason: "Reconciled", Message: "reconciled"},
+	}))
+}
+
+func TestHandleStateWithNone(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(state):
+    return None
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object.GetName()).To(Equal("test"))
+}
+
+func TestHandleStateWithError(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(state):
+    return {"error": {"reason": "InvalidSpec", "retryable": False}}
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+
+	herr, ok := err.(*handler.Error)
+	Expect(ok).To(BeTrue())
+	Expect(herr.Reason).To(Equal("InvalidSpec"))
+	Expect(herr.IsRetryable()).To(BeFalse())
+
+	h, err = newHandler(`
+def handle(state):
+    fail("failed")
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+}
+
+func TestHandleStateWithBudget(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(state):
+    for i in range(1000000):
+        pass
+    return state
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState())
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("too many steps"))
+}
+
+func TestHandleAdmissionRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(req):
+    return {"allowed": False, "status": {"reason": "denied"}}
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	res, err := h.HandleAdmissionRequest(admission.Request{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(res.Allowed).To(BeFalse())
+
+	h, err = newHandler(`
+def handle(req):
+    event("Normal", "Test", "test")
+    return {"allowed": True}
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = h.HandleAdmissionRequest(admission.Request{})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestNew(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := newHandler(`x = 1`)
+	Expect(err).To(HaveOccurred())
+
+	_, err = newHandler(`def handle(`)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestHandleStateConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler(`
+def handle(state):
+    obj = state["object"]
+    obj["status"] = {"name": obj["metadata"]["name"]}
+    return state
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := newState()
+			s.Object.SetName(name)
+
+			err := h.HandleState(s)
+			if err == nil {
+				status, _, _ := unstructured.NestedString(s.Object.Object, "status", "name")
+				if status != name {
+					err = fmt.Errorf("unexpected status: %s", status)
+				}
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
@@ -0,0 +1,347 @@
+package template
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+var log = logf.Log.WithName("handler")
+
+// TemplateHandler is a handler that renders the dependent resources from
+// the templates. The templates are executed with the state of the
+// resource and the rendered resources replace the dependent resources
+// of the state.
+// The parsed templates are only executed after New, which text/template
+// allows in parallel, and the rendered resources are written to the
+// state of each request.
+type TemplateHandler struct {
+	templates map[string]*template.Template
+	debug     bool
+	labels    metrics.Labels
+}
+
+func New(c *config.TemplateHandlerConfig, l metrics.Labels) (*TemplateHandler, error) {
+	templates := map[string]*template.Template{}
+
+	for key, text := range c.Dependents {
+		// Referring to a missing field is an error so that an invalid
+		// manifest is not rendered with '<no value>'.
+		t, err := template.New(key).Option("missingkey=error").Funcs(funcMap()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for %s: %v", key, err)
+		}
+		templates[key] = t
+	}
+
+	return &TemplateHandler{
+		templates: templates,
+		debug:     c.Debug,
+		labels:    l,
+	}, nil
+}
+
+func (h *TemplateHandler) HandleState(s *state.State) error {
+	start := time.Now()
+
+	err := h.render(s)
+	if err != nil {
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeError)
+		return err
+	}
+
+	metrics.ObserveHandler(h.labels, time.Since(start), "0")
+
+	return nil
+}
+
+// render renders the templates and sets the results to the dependents
+// of the state.
+func (h *TemplateHandler) render(s *state.State) error {
+	// The templates refer to the state by the same keys as the JSON
+	// payload of the other handlers.
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	data, err := decodeState(buf)
+	if err != nil {
+		return err
+	}
+
+	if s.Dependents == nil {
+		s.Dependents = map[string][]*unstructured.Unstructured{}
+	}
+
+	keys := []string{}
+	for key := range h.templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var out bytes.Buffer
+
+		err := h.templates[key].Execute(&out, data)
+		if err != nil {
+			return fmt.Errorf("failed to render template for %s: %v", key, err)
+		}
+
+		if h.debug {
+			log.Info("Rendered template", "dependent", key, "manifest", out.String())
+		}
+
+		objs, err := decodeManifest(out.Bytes())
+		if err != nil {
+			metrics.IncHandlerDecodeError(h.labels)
+			return fmt.Errorf("invalid manifest for %s: %v", key, err)
+		}
+
+		deps := []*unstructured.Unstructured{}
+		for _, obj := range objs {
+			if obj.GetNamespace() == "" && s.Object != nil {
+				obj.SetNamespace(s.Object.GetNamespace())
+			}
+
+			deps = append(deps, merge(s.Dependents[key], obj))
+		}
+
+		s.Dependents[key] = deps
+	}
+
+	return nil
+}
+
+// merge returns the current dependent resource overwritten by the fields
+// of the rendered resource. The fields that are set by the API server are
+// preserved so that unchanged resources are not updated. If the current
+// resource does not exist, the rendered resource is returned.
+func merge(current []*unstructured.Unstructured, obj *unstructured.Unstructured) *unstructured.Unstructured {
+	for _, cur := range current {
+		if cur.GetNamespace() != obj.GetNamespace() || cur.GetName() != obj.GetName() {
+			continue
+		}
+
+		merged := cur.DeepCopy()
+		mergeMap(merged.Object, obj.Object)
+
+		return merged
+	}
+
+	return obj
+}
+
+// mergeMap merges src into dst recursively. Values other than maps and
+// lists are replaced with the value of src.
+func mergeMap(dst, src map[string]interface{}) {
+	for key, val := range src {
+		switch srcVal := val.(type) {
+		case map[string]interface{}:
+			dstMap, ok := dst[key].(map[string]interface{})
+			if ok {
+				mergeMap(dstMap, srcVal)
+				continue
+			}
+		case []interface{}:
+			dstList, ok := dst[key].([]interface{})
+			if ok {
+				dst[key] = mergeList(dstList, srcVal)
+				continue
+			}
+		}
+
+		dst[key] = val
+	}
+}
+
+// mergeList merges the elements of src into the elements of dst and
+// returns the list that has the same length as src. The element that is
+// a map is merged with the element of dst that has the same name, or
+// with the element at the same index if it has no name. This preserves
+// the fields set by the API server in the elements, such as the
+// protocol of the ports.
+func mergeList(dst, src []interface{}) []interface{} {
+	list := make([]interface{}, len(src))
+
+	for i, val := range src {
+		srcMap, ok := val.(map[string]interface{})
+		if ok {
+			dstMap := findElement(dst, srcMap, i)
+			if dstMap != nil {
+				mergeMap(dstMap, srcMap)
+				list[i] = dstMap
+				continue
+			}
+		}
+
+		list[i] = val
+	}
+
+	return list
+}
+
+// findElement returns the map in the list that corresponds to the map
+// at the index i of another list.
+func findElement(list []interface{}, m map[string]interface{}, i int) map[string]interface{} {
+	if name, ok := m["name"].(string); ok {
+		for _, val := range list {
+			elem, ok := val.(map[string]interface{})
+			if ok && elem["name"] == name {
+				return elem
+			}
+		}
+
+		return nil
+	}
+
+	if i < len(list) {
+		elem, _ := list[i].(map[string]interface{})
+		return elem
+	}
+
+	return nil
+}
+
+// decodeManifest decodes the YAML documents in the manifest. Empty
+// documents are ignored.
+func decodeManifest(buf []byte) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(buf)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		j, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		// The document that has only comments is decoded to null.
+		if string(j) == "null" {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		err = obj.UnmarshalJSON(j)
+		if err != nil {
+			return nil, err
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+// decodeState decodes the JSON of the state for the templates. The
+// numbers are decoded as int64 if possible, so that the integers are not
+// rendered in the exponent format like 1e+06.
+func decodeState(buf []byte) (map[string]interface{}, error) {
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.UseNumber()
+
+	err := d.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = decodeNumbers(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid state: %s", string(buf))
+	}
+
+	return data, nil
+}
+
+// decodeNumbers replaces json.Number in the value with int64 or float64.
+func decodeNumbers(v interface{}) (interface{}, error) {
+	var err error
+
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		return val.Float64()
+	case map[string]interface{}:
+		for key, item := range val {
+			val[key], err = decodeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i], err = decodeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// funcMap returns the functions available in the templates.
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"toJson": func(v interface{}) (string, error) {
+			buf, err := json.Marshal(v)
+			return string(buf), err
+		},
+		"toYaml": func(v interface{}) (string, error) {
+			buf, err := yaml.Marshal(v)
+			return strings.TrimSuffix(string(buf), "\n"), err
+		},
+		"indent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+		},
+		"nindent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			return "\n" + pad + strings.Replace(s, "\n", "\n"+pad, -1)
+		},
+		"quote": func(v interface{}) string {
+			return fmt.Sprintf("%q", fmt.Sprint(v))
+		},
+		"default": func(d, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return d
+			}
+			return v
+		},
+	}
+}
@@ -0,0 +1,268 @@
+package template
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+const deploymentTemplate = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .object.metadata.name }}
+spec:
+  replicas: {{ index .object.spec "replicas" | default 1 }}
+  template:
+    spec:
+      containers:
+      - name: app
+        image: {{ .object.spec.image | quote }}
+`
+
+const serviceTemplate = `
+{{- if index .object.spec "port" }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .object.metadata.name }}
+  labels:
+    {{- toYaml .object.metadata.labels | nindent 4 }}
+spec:
+  ports:
+  - port: {{ .object.spec.port }}
+{{- end }}
+`
+
+func newState() *state.State {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("App")
+	obj.SetNamespace("default")
+	obj.SetName("test")
+	obj.SetLabels(map[string]string{"app": "test"})
+
+	unstructured.SetNestedField(obj.Object, "nginx:latest", "spec", "image")
+	unstructured.SetNestedField(obj.Object, int64(80), "spec", "port")
+
+	return &state.State{Object: obj}
+}
+
+func newHandler() (*TemplateHandler, error) {
+	c := &config.TemplateHandlerConfig{
+		Dependents: map[string]string{
+			"deployment.v1.apps": deploymentTemplate,
+			"service.v1":         serviceTemplate,
+		},
+	}
+
+	return New(c, metrics.Labels{})
+}
+
+func TestHandleState(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler()
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(s.Dependents["deployment.v1.apps"]).To(HaveLen(1))
+	deploy := s.Dependents["deployment.v1.apps"][0]
+	Expect(deploy.GetNamespace()).To(Equal("default"))
+	Expect(deploy.GetName()).To(Equal("test"))
+
+	replicas, _, _ := unstructured.NestedInt64(deploy.Object, "spec", "replicas")
+	Expect(replicas).To(Equal(int64(1)))
+
+	containers, _, _ := unstructured.NestedSlice(deploy.Object, "spec", "template", "spec", "containers")
+	Expect(containers).To(HaveLen(1))
+	Expect(containers[0].(map[string]interface{})["image"]).To(Equal("nginx:latest"))
+
+	Expect(s.Dependents["service.v1"]).To(HaveLen(1))
+	svc := s.Dependents["service.v1"][0]
+	Expect(svc.GetLabels()).To(Equal(map[string]string{"app": "test"}))
+
+	// The service is removed when the port is not specified.
+	s = newState()
+	unstructured.RemoveNestedField(s.Object.Object, "spec", "port")
+
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Dependents["service.v1"]).To(HaveLen(0))
+}
+
+func TestHandleStateWithCurrentDependents(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler()
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Simulate the fields set by the API server.
+	deploy := s.Dependents["deployment.v1.apps"][0]
+	deploy.SetResourceVersion("1")
+	unstructured.SetNestedField(deploy.Object, int64(1), "status", "replicas")
+
+	containers, _, _ := unstructured.NestedSlice(deploy.Object, "spec", "template", "spec", "containers")
+	containers[0].(map[string]interface{})["terminationMessagePath"] = "/dev/termination-log"
+	unstructured.SetNestedSlice(deploy.Object, containers, "spec", "template", "spec", "containers")
+
+	svc := s.Dependents["service.v1"][0]
+	ports, _, _ := unstructured.NestedSlice(svc.Object, "spec", "ports")
+	ports[0].(map[string]interface{})["protocol"] = "TCP"
+	unstructured.SetNestedSlice(svc.Object, ports, "spec", "ports")
+
+	current := s.Copy()
+
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Dependents["deployment.v1.apps"][0].GetResourceVersion()).To(Equal("1"))
+
+	created, updated, deleted := current.Diff(s)
+	Expect(created).To(HaveLen(0))
+	Expect(updated).To(HaveLen(0))
+	Expect(deleted).To(HaveLen(0))
+}
+
+func TestHandleStateWithLargeInteger(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.TemplateHandlerConfig{
+		Dependents: map[string]string{
+			"configmap.v1": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .object.metadata.name }}
+data:
+  replicas: {{ .object.spec.replicas | quote }}
+`,
+		},
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	unstructured.SetNestedField(s.Object.Object, int64(1000000), "spec", "replicas")
+
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	// The integer is not rendered as 1e+06.
+	cm := s.Dependents["configmap.v1"][0]
+	replicas, _, _ := unstructured.NestedString(cm.Object, "data", "replicas")
+	Expect(replicas).To(Equal("1000000"))
+}
+
+func TestHandleStateWithMissingField(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler()
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState()
+	unstructured.RemoveNestedField(s.Object.Object, "spec", "image")
+
+	err = h.HandleState(s)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestMergeList(t *testing.T) {
+	RegisterTestingT(t)
+
+	dst := []interface{}{
+		map[string]interface{}{"name": "a", "image": "a:v1", "default": "a"},
+		map[string]interface{}{"name": "b", "image": "b:v1", "default": "b"},
+	}
+	src := []interface{}{
+		map[string]interface{}{"name": "b", "image": "b:v2"},
+		map[string]interface{}{"name": "c", "image": "c:v1"},
+	}
+
+	// The elements are merged by name.
+	Expect(mergeList(dst, src)).To(Equal([]interface{}{
+		map[string]interface{}{"name": "b", "image": "b:v2", "default": "b"},
+		map[string]interface{}{"name": "c", "image": "c:v1"},
+	}))
+
+	dst = []interface{}{
+		map[string]interface{}{"port": int64(80), "protocol": "TCP"},
+		"value",
+	}
+	src = []interface{}{
+		map[string]interface{}{"port": int64(8080)},
+		"new",
+		"added",
+	}
+
+	// The elements without name are merged by index.
+	Expect(mergeList(dst, src)).To(Equal([]interface{}{
+		map[string]interface{}{"port": int64(8080), "protocol": "TCP"},
+		"new",
+		"added",
+	}))
+}
+
+func TestNew(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.TemplateHandlerConfig{
+		Dependents: map[string]string{
+			"deployment.v1.apps": "{{ .object",
+		},
+	}
+
+	_, err := New(c, metrics.Labels{})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestHandleStateConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := newHandler()
+	Expect(err).NotTo(HaveOccurred())
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := newState()
+			s.Object.SetName(name)
+
+			err := h.HandleState(s)
+			if err == nil {
+				deps := s.Dependents["deployment.v1.apps"]
+				if len(deps) != 1 || deps[0].GetName() != name {
+					err = fmt.Errorf("unexpected dependents: %v", deps)
+				}
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
@@ -0,0 +1,92 @@
+;; This is the handler used in the tests. handler.wasm is assembled from
+;; this file with a WAT assembler, such as 'wat2wasm handler.wat' of WABT.
+;;
+;; The module reads the state from stdin and writes it to stdout with the
+;; "handled" label. The object named "fail" results in an error, and the
+;; object named "loop" keeps calling a function forever.
+(module
+  (import "wasi_snapshot_preview1" "fd_read"
+    (func $fd_read (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write"
+    (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "proc_exit"
+    (func $proc_exit (param i32)))
+
+  ;; 0-7 is the iovec and 8-11 is the number of bytes read or written.
+  ;; The input is read into the buffer from 1024 to the end.
+  (memory (export "memory") 2)
+
+  (data (i32.const 16) "\"metadata\":{")
+  (data (i32.const 32) "\"labels\":{\"handled\":\"true\"},")
+  (data (i32.const 64) "\"name\":\"fail\"")
+  (data (i32.const 80) "\"name\":\"loop\"")
+  (data (i32.const 96) "{\"error\":{\"reason\":\"InvalidSpec\",\"retryable\":false}}\n")
+
+  ;; write writes the bytes to stdout.
+  (func $write (param $ptr i32) (param $len i32)
+    (i32.store (i32.const 0) (local.get $ptr))
+    (i32.store (i32.const 4) (local.get $len))
+    (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 8))))
+
+  ;; find returns the offset of the pattern in the input, or -1 if the
+  ;; input does not contain the pattern.
+  (func $find (param $len i32) (param $pat i32) (param $patlen i32) (result i32)
+    (local $i i32)
+    (local $j i32)
+    (block $notfound
+      (loop $outer
+        (br_if $notfound
+          (i32.gt_u (i32.add (local.get $i) (local.get $patlen)) (local.get $len)))
+        (local.set $j (i32.const 0))
+        (block $mismatch
+          (loop $inner
+            (if (i32.eq (local.get $j) (local.get $patlen))
+              (then (return (local.get $i))))
+            (br_if $mismatch
+              (i32.ne
+                (i32.load8_u (i32.add (i32.const 1024) (i32.add (local.get $i) (local.get $j))))
+                (i32.load8_u (i32.add (local.get $pat) (local.get $j)))))
+            (local.set $j (i32.add (local.get $j) (i32.const 1)))
+            (br $inner)))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br $outer)))
+    (i32.const -1))
+
+  ;; spin is called forever for the object named "loop".
+  (func $spin)
+
+  (func $main (export "_start")
+    (local $len i32)
+    (local $n i32)
+
+    ;; Read stdin until EOF.
+    (block $eof
+      (loop $read
+        (i32.store (i32.const 0) (i32.add (i32.const 1024) (local.get $len)))
+        (i32.store (i32.const 4) (i32.sub (i32.const 130048) (local.get $len)))
+        (br_if $eof (call $fd_read (i32.const 0) (i32.const 0) (i32.const 1) (i32.const 8)))
+        (local.set $n (i32.load (i32.const 8)))
+        (br_if $eof (i32.eqz (local.get $n)))
+        (local.set $len (i32.add (local.get $len) (local.get $n)))
+        (br $read)))
+
+    (if (i32.ge_s (call $find (local.get $len) (i32.const 64) (i32.const 13)) (i32.const 0))
+      (then
+        (call $write (i32.const 96) (i32.const 53))
+        (call $proc_exit (i32.const 1))))
+
+    (if (i32.ge_s (call $find (local.get $len) (i32.const 80) (i32.const 13)) (i32.const 0))
+      (then
+        (loop $forever
+          (call $spin)
+          (br $forever))))
+
+    (local.set $n (call $find (local.get $len) (i32.const 16) (i32.const 12)))
+    (if (i32.lt_s (local.get $n) (i32.const 0))
+      (then (call $proc_exit (i32.const 1))))
+
+    ;; Insert the labels at the beginning of the metadata.
+    (local.set $n (i32.add (local.get $n) (i32.const 12)))
+    (call $write (i32.const 1024) (local.get $n))
+    (call $write (i32.const 32) (i32.const 28))
+    (call $write (i32.add (i32.const 1024) (local.get $n)) (i32.sub (local.get $len) (local.get $n)))))
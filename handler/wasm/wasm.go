@@ -0,0 +1,309 @@
+package wasm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+	"github.com/summerwind/whitebox-controller/webhook/injection"
+)
+
+var log = logf.Log.WithName("handler")
+
+var defaultTimeout = 60 * time.Second
+
+// The number of WebAssembly memory pages in 1 MiB.
+const pagesPerMiB = 16
+
+var errFuelExhausted = errors.New("module exceeded the maximum number of calls")
+
+// WasmHandler is a handler that runs a WebAssembly module compiled for
+// WASI. The module reads the state from stdin and writes the next state
+// to stdout like the exec handler.
+// The runtime and the compiled module are shared by the requests, while
+// each request instantiates the module with its own memory and stdio.
+type WasmHandler struct {
+	runtime  wazero.Runtime
+	module   wazero.CompiledModule
+	args     []string
+	env      map[string]string
+	maxCalls uint64
+	timeout  time.Duration
+	debug    bool
+	labels   metrics.Labels
+}
+
+func New(c *config.WasmHandlerConfig, l metrics.Labels) (*WasmHandler, error) {
+	timeout := defaultTimeout
+	if c.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bin, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancel the running module when the context is done.
+	rc := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+
+	if c.MaxMemory > 0 {
+		rc = rc.WithMemoryLimitPages(uint32(c.MaxMemory * pagesPerMiB))
+	}
+
+	if c.CacheDir != "" {
+		cache, err := wazero.NewCompilationCacheWithDir(c.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		rc = rc.WithCompilationCache(cache)
+	}
+
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, rc)
+
+	_, err = wasi_snapshot_preview1.Instantiate(ctx, r)
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+
+	// The calls are counted only if the limit is specified since the
+	// listener is compiled into every function of the module.
+	cctx := ctx
+	if c.MaxCalls > 0 {
+		cctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, fuelListener{})
+	}
+
+	// The module is compiled once and instantiated for each request.
+	module, err := r.CompileModule(cctx, bin)
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+
+	args := []string{filepath.Base(c.File)}
+	if c.Args != nil {
+		args = append(args, c.Args...)
+	}
+
+	return &WasmHandler{
+		runtime:  r,
+		module:   module,
+		args:     args,
+		env:      c.Env,
+		maxCalls: c.MaxCalls,
+		timeout:  timeout,
+		debug:    c.Debug,
+		labels:   l,
+	}, nil
+}
+
+func (h *WasmHandler) HandleState(s *state.State) error {
+	in, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	out, err := h.run(in)
+	if err != nil {
+		return err
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(out, s)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return err
+	}
+
+	return nil
+}
+
+func (h *WasmHandler) HandleAdmissionRequest(req admission.Request) (admission.Response, error) {
+	res := admission.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	out, err := h.run(in)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (h *WasmHandler) HandleInjectionRequest(req injection.Request) (injection.Response, error) {
+	res := injection.Response{}
+
+	in, err := json.Marshal(&req)
+	if err != nil {
+		return res, err
+	}
+
+	out, err := h.run(in)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		metrics.IncHandlerDecodeError(h.labels)
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (h *WasmHandler) run(buf []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var f *fuel
+	if h.maxCalls > 0 {
+		f = &fuel{remaining: h.maxCalls, cancel: cancel}
+		ctx = context.WithValue(ctx, fuelKey{}, f)
+	}
+
+	// The empty name allows the module to be instantiated concurrently.
+	mc := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(h.args...).
+		WithStdin(bytes.NewReader(buf)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+
+	for key, val := range h.env {
+		mc = mc.WithEnv(key, val)
+	}
+
+	if h.debug {
+		log.Info("Sending state", "state", string(buf))
+	}
+
+	start := time.Now()
+	mod, err := h.runtime.InstantiateModule(ctx, h.module, mc)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+
+	code := 0
+	if err != nil {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) {
+			code = int(exitErr.ExitCode())
+		} else {
+			code = -1
+		}
+
+		// Exit code 0 means that the module exited successfully.
+		if code == 0 {
+			err = nil
+		}
+	}
+
+	if f != nil && f.exhausted {
+		err = errFuelExhausted
+	}
+
+	scanner := bufio.NewScanner(&stderr)
+	for scanner.Scan() {
+		log.Info(scanner.Text())
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		metrics.IncHandlerTimeout(h.labels)
+		metrics.ObserveHandler(h.labels, time.Since(start), metrics.CodeTimeout)
+	} else {
+		metrics.ObserveHandler(h.labels, time.Since(start), strconv.Itoa(code))
+	}
+	if err != nil {
+		if herr := handler.DecodeError(stdout.Bytes()); herr != nil {
+			return nil, herr
+		}
+		return nil, err
+	}
+
+	if h.debug {
+		log.Info("Received new state", "state", string(stdout.Bytes()), "code", code)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// fuelKey is the context key of the fuel of the request.
+type fuelKey struct{}
+
+// fuel is the number of the function calls that remain for the request.
+// The module runs in the goroutine of the request, so the fuel is not
+// shared by goroutines.
+type fuel struct {
+	remaining uint64
+	exhausted bool
+	cancel    context.CancelFunc
+}
+
+// fuelListener consumes the fuel on each function call of the module.
+// When the fuel runs out, it cancels the context of the request, and
+// the runtime closes the module.
+type fuelListener struct{}
+
+func (l fuelListener) NewListener(def api.FunctionDefinition) experimental.FunctionListener {
+	return l
+}
+
+func (l fuelListener) Before(ctx context.Context, mod api.Module, def api.FunctionDefinition, params []uint64, si experimental.StackIterator) context.Context {
+	f, ok := ctx.Value(fuelKey{}).(*fuel)
+	if !ok || f.exhausted {
+		return ctx
+	}
+
+	if f.remaining == 0 {
+		f.exhausted = true
+		f.cancel()
+		return ctx
+	}
+
+	f.remaining--
+
+	return ctx
+}
+
+func (l fuelListener) After(ctx context.Context, mod api.Module, def api.FunctionDefinition, err error, results []uint64) {
+}
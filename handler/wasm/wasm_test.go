@@ -0,0 +1,143 @@
+package wasm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
+	"github.com/summerwind/whitebox-controller/metrics"
+	"github.com/summerwind/whitebox-controller/reconciler/state"
+)
+
+// The module assembled from testdata/handler.wat.
+var modulePath = filepath.Join("testdata", "handler.wasm")
+
+func newState(name string) *state.State {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("whitebox.summerwind.dev/v1alpha1")
+	obj.SetKind("Test")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+
+	return &state.State{Object: obj}
+}
+
+func TestWasmHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "whitebox-wasm")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	c := &config.WasmHandlerConfig{
+		File:      modulePath,
+		MaxMemory: 256,
+		CacheDir:  dir,
+		Timeout:   "5s",
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState("test")
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object.GetLabels()["handled"]).To(Equal("true"))
+
+	err = h.HandleState(newState("fail"))
+	Expect(err).To(HaveOccurred())
+
+	herr, ok := err.(*handler.Error)
+	Expect(ok).To(BeTrue())
+	Expect(herr.Reason).To(Equal("InvalidSpec"))
+	Expect(herr.IsRetryable()).To(BeFalse())
+
+	// The module compiled from the cache.
+	h, err = New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	s = newState("test")
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object.GetLabels()["handled"]).To(Equal("true"))
+}
+
+func TestWasmHandlerWithTimeout(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.WasmHandlerConfig{
+		File:    modulePath,
+		Timeout: "500ms",
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = h.HandleState(newState("loop"))
+	Expect(err).To(HaveOccurred())
+}
+
+func TestWasmHandlerWithMaxCalls(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.WasmHandlerConfig{
+		File:     modulePath,
+		MaxCalls: 1000,
+		Timeout:  "5s",
+	}
+
+	h, err := New(c, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newState("test")
+	err = h.HandleState(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object.GetLabels()["handled"]).To(Equal("true"))
+
+	start := time.Now()
+	err = h.HandleState(newState("loop"))
+	Expect(err).To(Equal(errFuelExhausted))
+	Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+}
+
+func TestWasmHandlerConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	h, err := New(&config.WasmHandlerConfig{File: modulePath}, metrics.Labels{})
+	Expect(err).NotTo(HaveOccurred())
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("test-%d", i)
+			s := newState(name)
+
+			err := h.HandleState(s)
+			if err == nil && (s.Object.GetName() != name || s.Object.GetLabels()["handled"] != "true") {
+				err = fmt.Errorf("unexpected object: %v", s.Object)
+			}
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
@@ -0,0 +1,241 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+var dryRunLog = logf.Log.WithName("dry-run")
+
+// newDryRunClientFunc returns a function that creates the client for
+// dry-run mode. The client reads the resources as usual, but logs the
+// changes instead of writing them. If server-side is enabled, the
+// changes are also sent to the API server with dry-run option.
+func newDryRunClientFunc(c *config.DryRunConfig) manager.NewClientFunc {
+	return func(cache cache.Cache, kc *rest.Config, options client.Options) (client.Client, error) {
+		cl, err := client.New(kc, options)
+		if err != nil {
+			return nil, err
+		}
+
+		w := &dryRunWriter{
+			client:     cl,
+			serverSide: c.ServerSide,
+		}
+
+		return &client.DelegatingClient{
+			Reader: &client.DelegatingReader{
+				CacheReader:  cache,
+				ClientReader: cl,
+			},
+			Writer:       w,
+			StatusClient: w,
+		}, nil
+	}
+}
+
+// dryRunWriter is a client.Writer that logs the changes of the resources
+// instead of writing them.
+type dryRunWriter struct {
+	client     client.Client
+	serverSide bool
+}
+
+// Create implements client.Writer interface.
+func (w *dryRunWriter) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	logChange("create", obj, "object", string(buf))
+
+	if w.serverSide {
+		return w.client.Create(ctx, obj, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// Update implements client.Writer interface.
+func (w *dryRunWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	patch, err := w.diff(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	logChange("update", obj, "patch", string(patch))
+
+	if w.serverSide {
+		return w.client.Update(ctx, obj, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// Patch implements client.Writer interface.
+func (w *dryRunWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	buf, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	logChange("patch", obj, "patchType", string(patch.Type()), "patch", string(buf))
+
+	if w.serverSide {
+		return w.client.Patch(ctx, obj, patch, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// Delete implements client.Writer interface.
+func (w *dryRunWriter) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	logChange("delete", obj)
+
+	if w.serverSide {
+		return w.client.Delete(ctx, obj, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// DeleteAllOf implements client.Writer interface.
+func (w *dryRunWriter) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	logChange("delete-all", obj)
+
+	if w.serverSide {
+		dryRun := &client.DeleteAllOfOptions{
+			DeleteOptions: client.DeleteOptions{DryRun: []string{metav1.DryRunAll}},
+		}
+		return w.client.DeleteAllOf(ctx, obj, append(opts, dryRun)...)
+	}
+
+	return nil
+}
+
+// Status implements client.StatusClient interface.
+func (w *dryRunWriter) Status() client.StatusWriter {
+	return &dryRunStatusWriter{w}
+}
+
+// diff returns the JSON merge patch from the current object in the API
+// server to the specified object.
+func (w *dryRunWriter) diff(ctx context.Context, obj runtime.Object) ([]byte, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	current := obj.DeepCopyObject()
+	err = w.client.Get(ctx, client.ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()}, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current object: %v", err)
+	}
+
+	before, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonpatch.CreateMergePatch(before, after)
+}
+
+// dryRunStatusWriter is a client.StatusWriter that logs the changes of
+// the status subresource instead of writing them.
+type dryRunStatusWriter struct {
+	writer *dryRunWriter
+}
+
+// Update implements client.StatusWriter interface.
+func (w *dryRunStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	patch, err := w.writer.diff(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	logChange("update-status", obj, "patch", string(patch))
+
+	if w.writer.serverSide {
+		return w.writer.client.Status().Update(ctx, obj, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// Patch implements client.StatusWriter interface.
+func (w *dryRunStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	buf, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	logChange("patch-status", obj, "patchType", string(patch.Type()), "patch", string(buf))
+
+	if w.writer.serverSide {
+		return w.writer.client.Status().Patch(ctx, obj, patch, append(opts, client.DryRunAll)...)
+	}
+
+	return nil
+}
+
+// logChange logs the planned change of the object.
+func logChange(op string, obj runtime.Object, kv ...interface{}) {
+	values := []interface{}{
+		"operation", op,
+		"kind", obj.GetObjectKind().GroupVersionKind().Kind,
+	}
+
+	m, err := meta.Accessor(obj)
+	if err == nil {
+		values = append(values, "namespace", m.GetNamespace(), "name", m.GetName())
+	}
+
+	dryRunLog.Info("Planned change", append(values, kv...)...)
+}
+
+// dryRunBroadcaster is a record.EventBroadcaster that logs the events
+// instead of sending them to the API server.
+type dryRunBroadcaster struct {
+	record.EventBroadcaster
+}
+
+// newDryRunBroadcaster returns a new event broadcaster for dry-run mode.
+func newDryRunBroadcaster() record.EventBroadcaster {
+	return &dryRunBroadcaster{record.NewBroadcaster()}
+}
+
+// StartRecordingToSink implements record.EventBroadcaster interface.
+func (b *dryRunBroadcaster) StartRecordingToSink(sink record.EventSink) watch.Interface {
+	return b.StartEventWatcher(func(e *corev1.Event) {
+		dryRunLog.Info("Event",
+			"kind", e.InvolvedObject.Kind,
+			"namespace", e.InvolvedObject.Namespace,
+			"name", e.InvolvedObject.Name,
+			"type", e.Type,
+			"reason", e.Reason,
+			"message", e.Message,
+		)
+	})
+}
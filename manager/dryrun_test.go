@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+func newConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+		Data: map[string]string{
+			"key": "old",
+		},
+	}
+}
+
+func TestDryRunWriter(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.TODO()
+	key := types.NamespacedName{Namespace: "default", Name: "test"}
+
+	w := &dryRunWriter{client: fake.NewFakeClient()}
+
+	err := w.Create(ctx, newConfigMap())
+	Expect(err).NotTo(HaveOccurred())
+
+	err = w.client.Get(ctx, key, &corev1.ConfigMap{})
+	Expect(errors.IsNotFound(err)).To(BeTrue())
+
+	w = &dryRunWriter{client: fake.NewFakeClient(newConfigMap())}
+
+	cm := newConfigMap()
+	cm.Data["key"] = "new"
+
+	patch, err := w.diff(ctx, cm)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(patch)).To(Equal(`{"data":{"key":"new"}}`))
+
+	err = w.Update(ctx, cm)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = w.Status().Update(ctx, cm)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = w.Delete(ctx, cm)
+	Expect(err).NotTo(HaveOccurred())
+
+	current := &corev1.ConfigMap{}
+	err = w.client.Get(ctx, key, current)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(current.Data["key"]).To(Equal("old"))
+
+	err = w.Update(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "missing"}})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestNewOptionsWithDryRun(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := &config.Config{
+		LeaderElection: &config.LeaderElectionConfig{Enabled: true},
+	}
+
+	opts, err := newOptions(c)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(opts.LeaderElection).To(BeTrue())
+
+	c.DryRun = &config.DryRunConfig{Enabled: true}
+
+	opts, err = newOptions(c)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(opts.LeaderElection).To(BeFalse())
+	Expect(opts.NewClient).NotTo(BeNil())
+	Expect(opts.EventBroadcaster).NotTo(BeNil())
+}
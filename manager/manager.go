@@ -1,9 +1,18 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/summerwind/whitebox-controller/config"
@@ -17,15 +26,53 @@ func New(c *config.Config, kc *rest.Config) (manager.Manager, error) {
 		return nil, fmt.Errorf("invalid configuration: %v", err)
 	}
 
-	mgr, err := manager.New(kc, manager.Options{})
+	opts, err := newOptions(c)
 	if err != nil {
 		return nil, err
 	}
 
+	mgr, err := manager.New(kc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Health != nil {
+		err = mgr.AddHealthzCheck("ping", healthz.Ping)
+		if err != nil {
+			return nil, err
+		}
+
+		cc := &cacheChecker{
+			cache:   mgr.GetCache(),
+			objects: watchedObjects(c),
+		}
+		err = mgr.Add(cc)
+		if err != nil {
+			return nil, err
+		}
+
+		err = mgr.AddReadyzCheck("cache", cc.Check)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Metrics != nil {
+		ms, err := newMetricsServer(c.Metrics)
+		if err != nil {
+			return nil, err
+		}
+
+		err = mgr.Add(ms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	wh := false
 	for _, r := range c.Resources {
 		if r.Reconciler != nil {
-			_, err := controller.New(r, mgr)
+			_, err := controller.New(r, mgr, c.Namespaces)
 			if err != nil {
 				return nil, err
 			}
@@ -42,6 +89,13 @@ func New(c *config.Config, kc *rest.Config) (manager.Manager, error) {
 			return nil, err
 		}
 
+		if c.Health != nil {
+			err = mgr.AddReadyzCheck("webhook", server.Ready)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		for _, r := range c.Resources {
 			if r.Validator != nil {
 				server.AddValidator(r)
@@ -59,3 +113,181 @@ func New(c *config.Config, kc *rest.Config) (manager.Manager, error) {
 
 	return mgr, nil
 }
+
+// newOptions returns options for the manager based on specified config.
+func newOptions(c *config.Config) (manager.Options, error) {
+	opts := manager.Options{}
+
+	switch len(c.Namespaces) {
+	case 0:
+	case 1:
+		opts.Namespace = c.Namespaces[0]
+	default:
+		opts.NewCache = cache.MultiNamespacedCacheBuilder(c.Namespaces)
+	}
+
+	if c.Metrics != nil {
+		// The metrics are served by metricsServer because the manager
+		// serves them only at '/metrics'.
+		opts.MetricsBindAddress = "0"
+	}
+
+	if c.Health != nil {
+		opts.HealthProbeBindAddress = fmt.Sprintf("%s:%d", c.Health.Host, c.Health.Port)
+		opts.LivenessEndpointName = c.Health.LivenessPath
+		opts.ReadinessEndpointName = c.Health.ReadinessPath
+	}
+
+	if c.LeaderElection != nil && c.LeaderElection.Enabled {
+		le := c.LeaderElection
+
+		opts.LeaderElection = true
+		opts.LeaderElectionNamespace = le.Namespace
+		opts.LeaderElectionID = le.ID
+		if opts.LeaderElectionID == "" {
+			opts.LeaderElectionID = defaultLeaderElectionID(c)
+		}
+
+		var err error
+
+		opts.LeaseDuration, err = parseDuration(le.LeaseDuration)
+		if err != nil {
+			return opts, fmt.Errorf("invalid lease duration: %v", err)
+		}
+
+		opts.RenewDeadline, err = parseDuration(le.RenewDeadline)
+		if err != nil {
+			return opts, fmt.Errorf("invalid renew deadline: %v", err)
+		}
+
+		opts.RetryPeriod, err = parseDuration(le.RetryPeriod)
+		if err != nil {
+			return opts, fmt.Errorf("invalid retry period: %v", err)
+		}
+	}
+
+	if c.DryRun != nil && c.DryRun.Enabled {
+		opts.NewClient = newDryRunClientFunc(c.DryRun)
+		opts.EventBroadcaster = newDryRunBroadcaster()
+
+		// The controller in dry-run mode must not take the lease from
+		// the controller that is actually running.
+		if opts.LeaderElection {
+			dryRunLog.Info("Leader election is disabled in dry-run mode")
+			opts.LeaderElection = false
+		}
+	}
+
+	return opts, nil
+}
+
+// defaultLeaderElectionID returns the name of the configmap used for
+// leader election if it is not specified.
+func defaultLeaderElectionID(c *config.Config) string {
+	if c.Name != "" {
+		return fmt.Sprintf("%s-leader-election", c.Name)
+	}
+
+	return "whitebox-controller-leader-election"
+}
+
+// parseDuration parses the duration string. It returns nil if the
+// string is empty.
+func parseDuration(s string) (*time.Duration, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// cacheChecker is a runnable that reports readiness after the caches
+// of the resources watched by the controllers are synced. It gets the
+// informers itself because the controllers start their informers only
+// after winning the leader election.
+type cacheChecker struct {
+	cache   cache.Cache
+	objects []runtime.Object
+	synced  int32
+}
+
+// Start implements manager.Runnable interface.
+func (c *cacheChecker) Start(stop <-chan struct{}) error {
+	for _, obj := range c.objects {
+		// GetInformer starts the informer if needed and waits for it
+		// to sync.
+		_, err := c.cache.GetInformer(obj)
+		if err != nil {
+			return fmt.Errorf("failed to get informer: %v", err)
+		}
+	}
+
+	if !c.cache.WaitForCacheSync(stop) {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.synced, 1)
+	<-stop
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable interface.
+func (c *cacheChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// Check returns an error until the caches are synced.
+func (c *cacheChecker) Check(req *http.Request) error {
+	if atomic.LoadInt32(&c.synced) == 0 {
+		return errors.New("caches are not synced")
+	}
+
+	return nil
+}
+
+// watchedObjects returns the objects of the resources watched by the
+// controllers.
+func watchedObjects(c *config.Config) []runtime.Object {
+	gvks := []schema.GroupVersionKind{}
+	for _, r := range c.Resources {
+		if r.Reconciler == nil {
+			continue
+		}
+
+		gvks = append(gvks, r.GroupVersionKind)
+		for _, w := range r.Watches {
+			gvks = append(gvks, w.GroupVersionKind)
+		}
+
+		if r.Reconciler.Observe {
+			continue
+		}
+
+		for _, dep := range r.Dependents {
+			gvks = append(gvks, dep.GroupVersionKind)
+		}
+		for _, ref := range r.References {
+			gvks = append(gvks, ref.GroupVersionKind)
+		}
+	}
+
+	objs := []runtime.Object{}
+	seen := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range gvks {
+		if seen[gvk] {
+			continue
+		}
+		seen[gvk] = true
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		objs = append(objs, obj)
+	}
+
+	return objs
+}
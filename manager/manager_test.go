@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+func TestWatchedObjects(t *testing.T) {
+	RegisterTestingT(t)
+
+	parent := schema.GroupVersionKind{Group: "whitebox.summerwind.dev", Version: "v1alpha1", Kind: "Test"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+	c := &config.Config{
+		Resources: []*config.ResourceConfig{
+			{
+				GroupVersionKind: parent,
+				Dependents:       []config.DependentConfig{{GroupVersionKind: configMap}},
+				References:       []config.ReferenceConfig{{GroupVersionKind: configMap}, {GroupVersionKind: secret}},
+				Reconciler:       &config.ReconcilerConfig{},
+			},
+			{
+				GroupVersionKind: secret,
+				Dependents:       []config.DependentConfig{{GroupVersionKind: configMap}},
+				Reconciler:       &config.ReconcilerConfig{Observe: true},
+			},
+			{
+				GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			},
+		},
+	}
+
+	gvks := []schema.GroupVersionKind{}
+	for _, obj := range watchedObjects(c) {
+		gvks = append(gvks, obj.GetObjectKind().GroupVersionKind())
+	}
+
+	Expect(gvks).To(Equal([]schema.GroupVersionKind{parent, configMap, secret}))
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+// The path of the metrics endpoint if it is not specified.
+const defaultMetricsPath = "/metrics"
+
+// metricsServer is a runnable that serves the metrics registered in the
+// registry of controller-runtime at the configured path.
+type metricsServer struct {
+	listener net.Listener
+	path     string
+}
+
+// newMetricsServer returns a new metrics server. The listener is
+// created immediately so that the manager fails to be created if the
+// address is already in use.
+func newMetricsServer(c *config.MetricsConfig) (*metricsServer, error) {
+	l, err := metrics.NewListener(fmt.Sprintf("%s:%d", c.Host, c.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	path := c.Path
+	if path == "" {
+		path = defaultMetricsPath
+	}
+
+	return &metricsServer{
+		listener: l,
+		path:     path,
+	}, nil
+}
+
+// Start implements manager.Runnable interface.
+func (s *metricsServer) Start(stop <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.Handle(s.path, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+
+	server := &http.Server{Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(s.listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+		return server.Shutdown(context.Background())
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable interface.
+// The metrics are served on all replicas regardless of leader election.
+func (s *metricsServer) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/summerwind/whitebox-controller/config"
+)
+
+func TestMetricsServer(t *testing.T) {
+	RegisterTestingT(t)
+
+	s, err := newMetricsServer(&config.MetricsConfig{
+		Host: "127.0.0.1",
+		Port: 0,
+		Path: "/custom",
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- s.Start(stop)
+	}()
+
+	url := fmt.Sprintf("http://%s", s.listener.Addr())
+
+	res, err := http.Get(url + "/custom")
+	Expect(err).NotTo(HaveOccurred())
+	res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+	res, err = http.Get(url + "/metrics")
+	Expect(err).NotTo(HaveOccurred())
+	res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+
+	close(stop)
+	Expect(<-done).NotTo(HaveOccurred())
+}
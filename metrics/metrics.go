@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Kinds of handler used as the value of handler label.
+const (
+	HandlerReconciler = "reconciler"
+	HandlerFinalizer  = "finalizer"
+	HandlerValidator  = "validator"
+	HandlerMutator    = "mutator"
+	HandlerInjector   = "injector"
+)
+
+// Codes used as the value of code label when the handler does not
+// return an exit code or a status code.
+const (
+	CodeError   = "error"
+	CodeTimeout = "timeout"
+)
+
+// Operations used as the value of operation label.
+const (
+	OperationCreated = "created"
+	OperationUpdated = "updated"
+	OperationDeleted = "deleted"
+)
+
+var (
+	handlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "whitebox_handler_duration_seconds",
+		Help: "Length of time per handler invocation",
+	}, []string{"controller", "handler"})
+
+	handlerResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "whitebox_handler_results_total",
+		Help: "Total number of handler invocations per exit code or HTTP status",
+	}, []string{"controller", "handler", "code"})
+
+	handlerTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "whitebox_handler_timeouts_total",
+		Help: "Total number of handler invocations that timed out",
+	}, []string{"controller", "handler"})
+
+	handlerDecodeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "whitebox_handler_decode_errors_total",
+		Help: "Total number of handler outputs that could not be decoded",
+	}, []string{"controller", "handler"})
+
+	resourceChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "whitebox_resource_changes_total",
+		Help: "Total number of resources changed by handler",
+	}, []string{"controller", "handler", "resource", "operation"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		handlerDuration,
+		handlerResults,
+		handlerTimeouts,
+		handlerDecodeErrors,
+		resourceChanges,
+	)
+}
+
+// Labels identifies a handler in metrics.
+type Labels struct {
+	Controller string
+	Handler    string
+}
+
+// ObserveHandler records the duration and the result code of
+// a handler invocation.
+func ObserveHandler(l Labels, d time.Duration, code string) {
+	handlerDuration.WithLabelValues(l.Controller, l.Handler).Observe(d.Seconds())
+	handlerResults.WithLabelValues(l.Controller, l.Handler, code).Inc()
+}
+
+// IncHandlerTimeout increments the number of handler timeouts.
+func IncHandlerTimeout(l Labels) {
+	handlerTimeouts.WithLabelValues(l.Controller, l.Handler).Inc()
+}
+
+// IncHandlerDecodeError increments the number of handler outputs
+// that could not be decoded.
+func IncHandlerDecodeError(l Labels) {
+	handlerDecodeErrors.WithLabelValues(l.Controller, l.Handler).Inc()
+}
+
+// IncResourceChange increments the number of resources changed by
+// a handler with specified operation.
+func IncResourceChange(l Labels, resource, operation string) {
+	resourceChanges.WithLabelValues(l.Controller, l.Handler, resource, operation).Inc()
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestObserveHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := Labels{Controller: "observe", Handler: HandlerReconciler}
+
+	ObserveHandler(l, 2*time.Second, "0")
+	ObserveHandler(l, 1*time.Second, "0")
+	ObserveHandler(l, 1*time.Second, "1")
+
+	Expect(testutil.ToFloat64(handlerResults.WithLabelValues("observe", HandlerReconciler, "0"))).To(Equal(float64(2)))
+	Expect(testutil.ToFloat64(handlerResults.WithLabelValues("observe", HandlerReconciler, "1"))).To(Equal(float64(1)))
+
+	m := &dto.Metric{}
+	err := handlerDuration.WithLabelValues("observe", HandlerReconciler).(prometheus.Metric).Write(m)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(m.GetHistogram().GetSampleCount()).To(Equal(uint64(3)))
+	Expect(m.GetHistogram().GetSampleSum()).To(Equal(float64(4)))
+}
+
+func TestIncHandlerTimeout(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := Labels{Controller: "timeout", Handler: HandlerValidator}
+
+	IncHandlerTimeout(l)
+	IncHandlerTimeout(l)
+
+	Expect(testutil.ToFloat64(handlerTimeouts.WithLabelValues("timeout", HandlerValidator))).To(Equal(float64(2)))
+	Expect(testutil.ToFloat64(handlerTimeouts.WithLabelValues("timeout", HandlerMutator))).To(Equal(float64(0)))
+}
+
+func TestIncHandlerDecodeError(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := Labels{Controller: "decode", Handler: HandlerInjector}
+
+	IncHandlerDecodeError(l)
+
+	Expect(testutil.ToFloat64(handlerDecodeErrors.WithLabelValues("decode", HandlerInjector))).To(Equal(float64(1)))
+}
+
+func TestIncResourceChange(t *testing.T) {
+	RegisterTestingT(t)
+
+	l := Labels{Controller: "change", Handler: HandlerFinalizer}
+
+	IncResourceChange(l, "pod.v1", OperationCreated)
+	IncResourceChange(l, "pod.v1", OperationDeleted)
+	IncResourceChange(l, "pod.v1", OperationDeleted)
+
+	Expect(testutil.ToFloat64(resourceChanges.WithLabelValues("change", HandlerFinalizer, "pod.v1", OperationCreated))).To(Equal(float64(1)))
+	Expect(testutil.ToFloat64(resourceChanges.WithLabelValues("change", HandlerFinalizer, "pod.v1", OperationUpdated))).To(Equal(float64(0)))
+	Expect(testutil.ToFloat64(resourceChanges.WithLabelValues("change", HandlerFinalizer, "pod.v1", OperationDeleted))).To(Equal(float64(2)))
+}
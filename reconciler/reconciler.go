@@ -3,19 +3,28 @@ package reconciler
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
 
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/third_party/forked/golang/template"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -23,32 +32,95 @@ import (
 	"github.com/summerwind/whitebox-controller/config"
 	"github.com/summerwind/whitebox-controller/handler"
 	"github.com/summerwind/whitebox-controller/handler/common"
+	"github.com/summerwind/whitebox-controller/metrics"
 	"github.com/summerwind/whitebox-controller/reconciler/state"
 )
 
+// The name of field manager used for server-side apply.
+const fieldManager = "whitebox-controller"
+
+// The type of condition that indicates the result of handler.
+const conditionReconciled = "Reconciled"
+
+// The default backoff before retrying reconcile due to conflict.
+var defaultRetryBackoff = 100 * time.Millisecond
+
+// The default settings of rate limiter. These are the same as the
+// default rate limiter of the workqueue.
+var (
+	defaultBaseDelay = 5 * time.Millisecond
+	defaultMaxDelay  = 1000 * time.Second
+	defaultQPS       = 10.0
+	defaultBurst     = 100
+)
+
 var log = logf.Log.WithName("reconciler")
 
 // Reconciler represents a reconciler of controller.
 type Reconciler struct {
 	client.Client
-	config       *config.ResourceConfig
-	handler      handler.StateHandler
-	finalizer    handler.StateHandler
-	recorder     record.EventRecorder
-	requeueAfter *time.Duration
+	name             string
+	config           *config.ResourceConfig
+	handler          handler.StateHandler
+	finalizer        handler.StateHandler
+	recorder         record.EventRecorder
+	filter           func(metav1.Object) bool
+	requeueAfter     *time.Duration
+	updateStrategies map[string]string
+	retryAttempts    int
+	retryBackoff     time.Duration
+	cache            client.Reader
+	ownerIndex       string
+	rateLimiter      workqueue.RateLimiter
+	maxRetries       int
 }
 
 // New returns a new reconciler.
 func New(c *config.ResourceConfig, rec record.EventRecorder) (*Reconciler, error) {
-	h, err := common.NewStateHandler(&c.Reconciler.HandlerConfig)
+	name := fmt.Sprintf("%s-controller", strings.ToLower(c.Kind))
+
+	h, err := common.NewStateHandler(&c.Reconciler.HandlerConfig, metrics.Labels{
+		Controller: name,
+		Handler:    metrics.HandlerReconciler,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	r := &Reconciler{
-		config:   c,
-		handler:  h,
-		recorder: rec,
+		name:             name,
+		config:           c,
+		handler:          h,
+		recorder:         rec,
+		updateStrategies: map[string]string{},
+	}
+
+	if c.Reconciler.ConflictRetry != nil {
+		r.retryAttempts = c.Reconciler.ConflictRetry.Attempts
+		r.retryBackoff = defaultRetryBackoff
+		if c.Reconciler.ConflictRetry.Backoff != "" {
+			b, err := time.ParseDuration(c.Reconciler.ConflictRetry.Backoff)
+			if err != nil {
+				return nil, errors.New("invalid conflict retry backoff")
+			}
+			r.retryBackoff = b
+		}
+	}
+
+	if c.Reconciler.RateLimit != nil {
+		rl, err := newRateLimiter(c.Reconciler.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		r.rateLimiter = rl
+		r.maxRetries = c.Reconciler.RateLimit.MaxRetries
+	}
+
+	for _, dep := range c.Dependents {
+		if dep.UpdateStrategy == config.UpdateStrategyStrategicMerge && !scheme.Scheme.Recognizes(dep.GroupVersionKind) {
+			return nil, fmt.Errorf("strategic merge patch is not supported for %s", dep.GroupVersionKind)
+		}
+		r.updateStrategies[state.ResourceKey(dep.GroupVersionKind)] = dep.UpdateStrategy
 	}
 
 	if c.Reconciler.RequeueAfter != "" {
@@ -60,7 +132,10 @@ func New(c *config.ResourceConfig, rec record.EventRecorder) (*Reconciler, error
 	}
 
 	if c.Finalizer != nil {
-		fh, err := common.NewStateHandler(c.Finalizer)
+		fh, err := common.NewStateHandler(c.Finalizer, metrics.Labels{
+			Controller: name,
+			Handler:    metrics.HandlerFinalizer,
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -70,23 +145,158 @@ func New(c *config.ResourceConfig, rec record.EventRecorder) (*Reconciler, error
 	return r, nil
 }
 
+// Handlers returns the handlers of the reconciler and the finalizer.
+func (r *Reconciler) Handlers() []handler.StateHandler {
+	handlers := []handler.StateHandler{r.handler}
+	if r.finalizer != nil {
+		handlers = append(handlers, r.finalizer)
+	}
+
+	return handlers
+}
+
 // InjectClient implements inject.Client interface.
 func (r *Reconciler) InjectClient(c client.Client) error {
 	r.Client = c
 	return nil
 }
 
-// Reconcile reconciles specified object.
+// InjectCache implements inject.Cache interface. It registers the field
+// index of the controller owner UID for dependent resources so that the
+// dependent resources can be listed from the cache without filtering.
+func (r *Reconciler) InjectCache(c cache.Cache) error {
+	// Observer does not read dependent resources.
+	if r.IsObserver() {
+		return nil
+	}
+
+	field := fmt.Sprintf("metadata.ownerReferences.%s", state.ResourceKey(r.config.GroupVersionKind))
+
+	indexed := map[string]bool{}
+	for _, dep := range r.config.Dependents {
+		key := state.ResourceKey(dep.GroupVersionKind)
+		if indexed[key] {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(dep.GroupVersionKind)
+
+		err := c.IndexField(obj, field, r.indexOwner)
+		if err != nil {
+			return fmt.Errorf("failed to index dependent resource %s: %v", key, err)
+		}
+
+		indexed[key] = true
+	}
+
+	r.cache = c
+	r.ownerIndex = field
+
+	return nil
+}
+
+// indexOwner returns the UID of the controller owner if the owner is
+// the kind of the resource.
+func (r *Reconciler) indexOwner(obj runtime.Object) []string {
+	res, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	ownerRef := metav1.GetControllerOf(res)
+	if ownerRef == nil {
+		return nil
+	}
+
+	gv := r.config.GroupVersionKind.GroupVersion()
+	if ownerRef.APIVersion != gv.String() || ownerRef.Kind != r.config.Kind {
+		return nil
+	}
+
+	return []string{string(ownerRef.UID)}
+}
+
+// Reconcile reconciles specified object. If the rate limiter is
+// configured, the failed object is requeued with the delay of the rate
+// limiter, or the delay specified by the handler error, and it is
+// dropped after the maximum number of retries. The rate limiter is only
+// used for the retries: the reconciliation triggered by events is not
+// limited.
 func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	if r.IsObserver() {
+		return r.Observe(req)
+	}
+
+	result, err := r.reconcileWithRetry(req)
+	if r.rateLimiter == nil {
+		// The result is ignored by the controller if an error is
+		// returned.
+		if err != nil && result.RequeueAfter > 0 {
+			return result, nil
+		}
+		return result, err
+	}
+
+	if err == nil {
+		r.rateLimiter.Forget(req)
+		return result, nil
+	}
+
+	retries := r.rateLimiter.NumRequeues(req)
+	if r.maxRetries > 0 && retries >= r.maxRetries {
+		log.Error(err, "Dropped a resource due to too many retries", "namespace", req.Namespace, "name", req.Name, "retries", retries)
+		r.recordDrop(req, retries, err)
+		r.rateLimiter.Forget(req)
+		return reconcile.Result{}, nil
+	}
+
+	// The failure is counted by When even if the handler specifies the
+	// delay.
+	delay := r.rateLimiter.When(req)
+	if result.RequeueAfter > 0 {
+		delay = result.RequeueAfter
+	}
+	log.Info("Retrying reconcile due to error", "namespace", req.Namespace, "name", req.Name, "after", delay.String(), "error", err.Error())
+
+	return reconcile.Result{RequeueAfter: delay}, nil
+}
+
+// reconcileWithRetry reconciles specified object. If writing the new
+// state fails with a conflict, the reconciliation is retried with the
+// latest object up to the configured number of attempts.
+func (r *Reconciler) reconcileWithRetry(req reconcile.Request) (reconcile.Result, error) {
+	if r.config.Reconciler.ConflictRetry == nil {
+		return r.reconcile(req)
+	}
+
+	backoff := r.retryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := r.reconcile(req)
+		if err == nil || !apierrors.IsConflict(err) {
+			return result, err
+		}
+
+		if attempt >= r.retryAttempts {
+			log.Error(err, "Conflict retries exhausted", "namespace", req.Namespace, "name", req.Name, "attempts", attempt)
+			r.recordConflict(req, attempt)
+			return result, err
+		}
+
+		log.Info("Retrying reconcile due to conflict", "namespace", req.Namespace, "name", req.Name, "attempt", attempt)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// reconcile runs the handler for specified object once and writes
+// the new state.
+func (r *Reconciler) reconcile(req reconcile.Request) (reconcile.Result, error) {
 	var (
 		err       error
 		finalized bool
 	)
 
-	if r.IsObserver() {
-		return r.Observe(req)
-	}
-
 	namespace := req.Namespace
 	name := req.Name
 	log.Info("Reconcile a resource", "namespace", namespace, "name", name)
@@ -103,6 +313,11 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 		return reconcile.Result{}, err
 	}
 
+	if r.isFiltered(instance) {
+		log.Info("Skipped a resource excluded by the filter", "namespace", namespace, "name", name)
+		return reconcile.Result{}, nil
+	}
+
 	dependents, err := r.getDependents(instance)
 	if err != nil {
 		log.Error(err, "Failed to get dependent resources", "namespace", namespace, "name", name)
@@ -127,9 +342,26 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 	}
 	if err != nil {
 		log.Error(err, "Handler error", "namespace", namespace, "name", name)
+		if herr, ok := err.(*handler.Error); ok {
+			return r.handleError(instance, herr)
+		}
 		return reconcile.Result{}, err
 	}
 
+	if ns.Object != nil {
+		err = resetErrorCondition(ns.Object)
+		if err != nil {
+			log.Error(err, "Failed to reset the condition", "namespace", namespace, "name", name)
+			return reconcile.Result{}, err
+		}
+
+		err = mergeConditions(ns)
+		if err != nil {
+			log.Error(err, "Failed to merge the conditions", "namespace", namespace, "name", name)
+			return reconcile.Result{}, err
+		}
+	}
+
 	err = r.validateState(s, ns)
 	if err != nil {
 		log.Error(err, "The new state is invalid", "namespace", namespace, "name", name)
@@ -146,26 +378,40 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 		r.setFinalizer(ns.Object)
 	}
 
+	labels := metrics.Labels{
+		Controller: r.name,
+		Handler:    metrics.HandlerReconciler,
+	}
+	if finalized {
+		labels.Handler = metrics.HandlerFinalizer
+	}
+
 	created, updated, deleted := s.Diff(ns)
 
 	for _, res := range created {
 		log.Info("Creating resource", "kind", res.GetKind(), "namespace", res.GetNamespace(), "name", res.GetName())
 
-		err = r.Create(context.TODO(), res)
+		err = r.createDependent(res)
 		if err != nil {
 			log.Error(err, "Failed to create a resource", "namespace", res.GetNamespace(), "name", res.GetName())
 			return reconcile.Result{}, err
 		}
+		metrics.IncResourceChange(labels, state.ResourceKey(res.GroupVersionKind()), metrics.OperationCreated)
 	}
 
 	for _, res := range updated {
 		log.Info("Updating resource", "kind", res.GetKind(), "namespace", res.GetNamespace(), "name", res.GetName())
 
-		err = r.Update(context.TODO(), res)
+		if res == ns.Object {
+			err = r.updateObject(s.Object, res)
+		} else {
+			err = r.updateDependent(findDependent(s, res), res)
+		}
 		if err != nil {
 			log.Error(err, "Failed to update a resource", "namespace", res.GetNamespace(), "name", res.GetName())
 			return reconcile.Result{}, err
 		}
+		metrics.IncResourceChange(labels, state.ResourceKey(res.GroupVersionKind()), metrics.OperationUpdated)
 	}
 
 	for _, res := range deleted {
@@ -176,6 +422,15 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 			log.Error(err, "Failed to delete a resource", "namespace", res.GetNamespace(), "name", res.GetName())
 			return reconcile.Result{}, err
 		}
+		metrics.IncResourceChange(labels, state.ResourceKey(res.GroupVersionKind()), metrics.OperationDeleted)
+	}
+
+	if r.config.ObservedGeneration && ns.Object != nil && !finalized {
+		err = r.updateObservedGeneration(ns.Object)
+		if err != nil {
+			log.Error(err, "Failed to update the observed generation", "namespace", namespace, "name", name)
+			return reconcile.Result{}, err
+		}
 	}
 
 	for _, ev := range ns.Events {
@@ -200,6 +455,67 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 	return result, nil
 }
 
+// handleError records the structured error of handler as the condition
+// and the warning event of the object, and returns the result of reconcile
+// based on the error. The retryable error is returned even if the delay
+// is specified so that Reconcile counts the failure.
+func (r *Reconciler) handleError(instance *unstructured.Unstructured, herr *handler.Error) (reconcile.Result, error) {
+	r.recorder.Event(instance, corev1.EventTypeWarning, herr.Reason, herr.Message)
+
+	res := instance.DeepCopy()
+	err := state.SetCondition(res, state.Condition{
+		Type:               conditionReconciled,
+		Status:             state.ConditionFalse,
+		Reason:             herr.Reason,
+		Message:            herr.Message,
+		ObservedGeneration: res.GetGeneration(),
+	})
+	if err == nil && !reflect.DeepEqual(instance, res) {
+		err = r.updateObject(instance, res)
+	}
+	if err != nil {
+		log.Error(err, "Failed to update the condition", "namespace", instance.GetNamespace(), "name", instance.GetName())
+	}
+
+	if !herr.IsRetryable() {
+		return reconcile.Result{}, nil
+	}
+
+	if herr.RequeueAfter > 0 {
+		return reconcile.Result{RequeueAfter: time.Duration(herr.RequeueAfter) * time.Second}, herr
+	}
+
+	return reconcile.Result{}, herr
+}
+
+// recordConflict records a warning event for the object that could not
+// be reconciled due to conflicts.
+func (r *Reconciler) recordConflict(req reconcile.Request, attempts int) {
+	instance := &unstructured.Unstructured{}
+	instance.SetGroupVersionKind(r.config.GroupVersionKind)
+
+	err := r.Get(context.TODO(), req.NamespacedName, instance)
+	if err != nil {
+		return
+	}
+
+	r.recorder.Eventf(instance, corev1.EventTypeWarning, "ConflictRetriesExhausted", "Failed to write the new state after %d attempts due to conflicts", attempts)
+}
+
+// recordDrop records a warning event for the object that is dropped
+// after the maximum number of retries.
+func (r *Reconciler) recordDrop(req reconcile.Request, retries int, cause error) {
+	instance := &unstructured.Unstructured{}
+	instance.SetGroupVersionKind(r.config.GroupVersionKind)
+
+	err := r.Get(context.TODO(), req.NamespacedName, instance)
+	if err != nil {
+		return
+	}
+
+	r.recorder.Eventf(instance, corev1.EventTypeWarning, "RetriesExhausted", "Failed to reconcile after %d retries: %v", retries, cause)
+}
+
 func (r *Reconciler) Observe(req reconcile.Request) (reconcile.Result, error) {
 	namespace := req.Namespace
 	name := req.Name
@@ -213,6 +529,11 @@ func (r *Reconciler) Observe(req reconcile.Request) (reconcile.Result, error) {
 		return reconcile.Result{}, nil
 	}
 
+	if err == nil && r.isFiltered(instance) {
+		log.Info("Skipped a resource excluded by the filter", "namespace", namespace, "name", name)
+		return reconcile.Result{}, nil
+	}
+
 	// This allows determination of deleted resources
 	instance.SetNamespace(namespace)
 	instance.SetName(name)
@@ -235,7 +556,10 @@ func (r *Reconciler) IsObserver() bool {
 }
 
 // getDependents returns a list of dependent resources with
-// an specified owner reference.
+// an specified owner reference. If the resource is cluster-scoped,
+// dependent resources are searched in all namespaces. If the cache is
+// injected, dependent resources are listed from the cache by the field
+// index of the owner UID.
 func (r *Reconciler) getDependents(res *unstructured.Unstructured) (map[string][]*unstructured.Unstructured, error) {
 	dependents := map[string][]*unstructured.Unstructured{}
 	ownerRef := metav1.NewControllerRef(res, res.GroupVersionKind())
@@ -249,6 +573,18 @@ func (r *Reconciler) getDependents(res *unstructured.Unstructured) (map[string][
 		dependentList := &unstructured.UnstructuredList{}
 		dependentList.SetGroupVersionKind(gvk)
 
+		if r.cache != nil {
+			err := r.cache.List(context.TODO(), dependentList, client.InNamespace(res.GetNamespace()), client.MatchingFields{r.ownerIndex: string(res.GetUID())})
+			if err != nil {
+				return nil, fmt.Errorf("Failed to get a list for dependent resource: %v", err)
+			}
+
+			for i := range dependentList.Items {
+				dependents[key] = append(dependents[key], &dependentList.Items[i])
+			}
+			continue
+		}
+
 		err := r.List(context.TODO(), dependentList, client.InNamespace(res.GetNamespace()))
 		if err != nil {
 			return nil, fmt.Errorf("Failed to get a list for dependent resource: %v", err)
@@ -274,45 +610,315 @@ func (r *Reconciler) getReferences(res *unstructured.Unstructured) (map[string][
 	refs := map[string][]*unstructured.Unstructured{}
 
 	for _, ref := range r.config.References {
-		if ref.NameFieldPath == "" {
+		if ref.NameFieldPath == "" && ref.SelectorFieldPath == "" {
 			continue
 		}
 
 		key := state.ResourceKey(ref.GroupVersionKind)
 		refs[key] = []*unstructured.Unstructured{}
 
-		refNames, err := getReferenceNames(res, ref.NameFieldPath)
+		namespace, err := getReferenceNamespace(res, ref.NamespaceFieldPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get reference name list: %v", err)
+			return nil, fmt.Errorf("failed to get reference namespace: %v", err)
 		}
 
-		if len(refNames) == 0 {
-			continue
+		var refList []*unstructured.Unstructured
+		if ref.SelectorFieldPath != "" {
+			refList, err = r.getReferencesBySelector(res, ref, namespace)
+		} else {
+			refList, err = r.getReferencesByName(res, ref, namespace)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		refs[key] = append(refs[key], refList...)
+	}
+
+	return refs, nil
+}
+
+// getReferencesByName returns the reference resources that have the
+// names specified by the name field path of the resource.
+func (r *Reconciler) getReferencesByName(res *unstructured.Unstructured, ref config.ReferenceConfig, namespace string) ([]*unstructured.Unstructured, error) {
+	refs := []*unstructured.Unstructured{}
+
+	refNames, err := getReferenceNames(res, ref.NameFieldPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference name list: %v", err)
+	}
+
+	for i := range refNames {
+		refRes := &unstructured.Unstructured{}
+		refRes.SetGroupVersionKind(ref.GroupVersionKind)
+
+		nn, err := getReferenceName(namespace, refNames[i])
+		if err != nil {
+			return nil, err
 		}
 
-		for i := range refNames {
-			refRes := &unstructured.Unstructured{}
-			refRes.SetGroupVersionKind(ref.GroupVersionKind)
+		if !ref.IsAllowedNamespace(res.GetNamespace(), nn.Namespace) {
+			log.Info("Ignored reference due to the namespace is not allowed", "namespace", res.GetNamespace(), "name", res.GetName(), "reference", nn.String())
+			continue
+		}
 
-			nn := types.NamespacedName{
-				Namespace: res.GetNamespace(),
-				Name:      refNames[i],
-			}
-			err = r.Get(context.TODO(), nn, refRes)
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					continue
-				}
-				return nil, fmt.Errorf("failed to get a resource '%s/%s': %v", res.GetNamespace(), refNames[i], err)
+		err = r.Get(context.TODO(), nn, refRes)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
 			}
-
-			refs[key] = append(refs[key], refRes)
+			return nil, fmt.Errorf("failed to get a resource '%s': %v", nn, err)
 		}
+
+		refs = append(refs, refRes)
+	}
+
+	return refs, nil
+}
+
+// getReferencesBySelector returns the reference resources that match
+// the label selector specified by the selector field path of the resource.
+func (r *Reconciler) getReferencesBySelector(res *unstructured.Unstructured, ref config.ReferenceConfig, namespace string) ([]*unstructured.Unstructured, error) {
+	refs := []*unstructured.Unstructured{}
+
+	selector, err := getReferenceSelector(res, ref.SelectorFieldPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference selector: %v", err)
+	}
+
+	if selector == nil {
+		return refs, nil
+	}
+
+	if !ref.IsAllowedNamespace(res.GetNamespace(), namespace) {
+		log.Info("Ignored reference due to the namespace is not allowed", "namespace", res.GetNamespace(), "name", res.GetName(), "reference", namespace)
+		return refs, nil
+	}
+
+	refList := &unstructured.UnstructuredList{}
+	refList.SetGroupVersionKind(ref.GroupVersionKind)
+
+	err = r.List(context.TODO(), refList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %v", err)
+	}
+
+	for i := range refList.Items {
+		refs = append(refs, &refList.Items[i])
 	}
 
 	return refs, nil
 }
 
+// ReferenceKeys returns the keys in 'namespace/name' format of the
+// resources referred to by name from the resource. The keys of the
+// resources in the namespaces that are not allowed are excluded.
+func ReferenceKeys(res *unstructured.Unstructured, ref config.ReferenceConfig) ([]string, error) {
+	keys := []string{}
+
+	if ref.NameFieldPath == "" {
+		return keys, nil
+	}
+
+	namespace, err := getReferenceNamespace(res, ref.NamespaceFieldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	refNames, err := getReferenceNames(res, ref.NameFieldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range refNames {
+		nn, err := getReferenceName(namespace, refNames[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if !ref.IsAllowedNamespace(res.GetNamespace(), nn.Namespace) {
+			continue
+		}
+		keys = append(keys, nn.String())
+	}
+
+	return keys, nil
+}
+
+// FieldValues returns the values of the field of the resource specified
+// by the JSON path.
+func FieldValues(res *unstructured.Unstructured, path string) ([]string, error) {
+	return getReferenceNames(res, path)
+}
+
+// SelectsReference returns whether the resource refers to the object
+// with the label selector of the reference.
+func SelectsReference(res *unstructured.Unstructured, ref config.ReferenceConfig, obj metav1.Object) (bool, error) {
+	if ref.SelectorFieldPath == "" {
+		return false, nil
+	}
+
+	namespace, err := getReferenceNamespace(res, ref.NamespaceFieldPath)
+	if err != nil {
+		return false, err
+	}
+
+	if obj.GetNamespace() != namespace || !ref.IsAllowedNamespace(res.GetNamespace(), namespace) {
+		return false, nil
+	}
+
+	selector, err := getReferenceSelector(res, ref.SelectorFieldPath)
+	if err != nil {
+		return false, err
+	}
+
+	if selector == nil {
+		return false, nil
+	}
+
+	return selector.Matches(labels.Set(obj.GetLabels())), nil
+}
+
+// updateObject updates the object of the resource. If the resource
+// has the status subresource, the status is updated through the status
+// client separately from the rest of the object.
+func (r *Reconciler) updateObject(old, res *unstructured.Unstructured) error {
+	if !r.config.StatusSubresource {
+		return r.Update(context.TODO(), res)
+	}
+
+	oldStatus, _, err := unstructured.NestedFieldCopy(old.Object, "status")
+	if err != nil {
+		return err
+	}
+
+	status, hasStatus, err := unstructured.NestedFieldCopy(res.Object, "status")
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(withoutStatus(old), withoutStatus(res)) {
+		err = r.Update(context.TODO(), res)
+		if err != nil {
+			return err
+		}
+	}
+
+	if reflect.DeepEqual(oldStatus, status) {
+		return nil
+	}
+
+	// Restore the status since the response of update overwrites it.
+	if hasStatus {
+		err = unstructured.SetNestedField(res.Object, status, "status")
+		if err != nil {
+			return err
+		}
+	} else {
+		unstructured.RemoveNestedField(res.Object, "status")
+	}
+
+	return r.Status().Update(context.TODO(), res)
+}
+
+// updateObservedGeneration sets the generation of the object to the
+// observed generation in the status of the object.
+func (r *Reconciler) updateObservedGeneration(res *unstructured.Unstructured) error {
+	generation := res.GetGeneration()
+
+	observed, found, err := unstructured.NestedInt64(res.Object, "status", "observedGeneration")
+	if err == nil && found && observed == generation {
+		return nil
+	}
+
+	old := res.DeepCopy()
+	err = unstructured.SetNestedField(res.Object, generation, "status", "observedGeneration")
+	if err != nil {
+		return err
+	}
+
+	return r.updateObject(old, res)
+}
+
+// createDependent creates the dependent resource. If the update
+// strategy of its kind is 'apply', the resource is created with
+// server-side apply so that the fields are owned by the field manager
+// of the controller from the beginning.
+func (r *Reconciler) createDependent(res *unstructured.Unstructured) error {
+	strategy := r.updateStrategies[state.ResourceKey(res.GroupVersionKind())]
+	if strategy == config.UpdateStrategyApply {
+		return r.apply(res)
+	}
+
+	return r.Create(context.TODO(), res)
+}
+
+// updateDependent updates the dependent resource with the update
+// strategy configured for its kind.
+func (r *Reconciler) updateDependent(old, res *unstructured.Unstructured) error {
+	strategy := r.updateStrategies[state.ResourceKey(res.GroupVersionKind())]
+
+	switch strategy {
+	case config.UpdateStrategyMerge:
+		if old == nil {
+			break
+		}
+		return r.Patch(context.TODO(), res, client.MergeFrom(old))
+	case config.UpdateStrategyStrategicMerge:
+		if old == nil {
+			break
+		}
+		return r.Patch(context.TODO(), res, &strategicMergePatch{from: old})
+	case config.UpdateStrategyApply:
+		return r.apply(res)
+	}
+
+	return r.Update(context.TODO(), res)
+}
+
+// apply applies the resource with server-side apply. The ownership of
+// the fields is not forced, so changing a field that is owned by another
+// field manager fails with a conflict instead of taking it over.
+func (r *Reconciler) apply(res *unstructured.Unstructured) error {
+	obj := newApplyObject(res)
+
+	err := r.Patch(context.TODO(), obj, client.Apply, client.FieldOwner(fieldManager))
+	if err != nil {
+		return err
+	}
+
+	obj.DeepCopyInto(res)
+	return nil
+}
+
+// serverFields are the fields of metadata that are populated by the API
+// server and must not be owned by the controller.
+var serverFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"selfLink",
+	"managedFields",
+}
+
+// newApplyObject returns the copy of the resource to be sent with
+// server-side apply. The fields populated by the API server and the
+// status are removed so that the controller owns only the fields that
+// are set by the handler.
+func newApplyObject(res *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := res.DeepCopy()
+
+	for _, field := range serverFields {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	return obj
+}
+
 // setFinalizer adds it's finalizer name to resource's metadata.
 func (r *Reconciler) setFinalizer(res *unstructured.Unstructured) {
 	if res == nil {
@@ -359,6 +965,32 @@ func (r *Reconciler) unsetFinalizer(res *unstructured.Unstructured) {
 	}
 }
 
+// SetFilter sets the function that reports whether the resource should
+// be reconciled. If it is not set, all resources are reconciled.
+func (r *Reconciler) SetFilter(f func(metav1.Object) bool) {
+	r.filter = f
+}
+
+// isFiltered returns whether the resource is excluded by the filter.
+// The resource being finalized by the controller is not excluded so
+// that the finalizer can be removed.
+func (r *Reconciler) isFiltered(res *unstructured.Unstructured) bool {
+	if r.filter == nil || r.filter(res) {
+		return false
+	}
+
+	if isDeleting(res) && r.finalizer != nil {
+		name := r.getFinalizerName()
+		for _, f := range res.GetFinalizers() {
+			if f == name {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 // getFinalizerName returns controller's finalizer name.
 func (r *Reconciler) getFinalizerName() string {
 	return fmt.Sprintf("%s-controller.%s", strings.ToLower(r.config.Kind), r.config.Group)
@@ -381,13 +1013,13 @@ func (r *Reconciler) validateState(s, ns *state.State) error {
 		}
 	}
 
-	keys := map[string]struct{}{}
+	scopes := map[string]bool{}
 	for _, dep := range r.config.Dependents {
-		keys[state.ResourceKey(dep.GroupVersionKind)] = struct{}{}
+		scopes[state.ResourceKey(dep.GroupVersionKind)] = dep.IsClusterScoped()
 	}
 
 	for key := range ns.Dependents {
-		_, ok := keys[key]
+		clusterScoped, ok := scopes[key]
 		if !ok {
 			return fmt.Errorf("dependents[%s]: unexpected group/version/kind", key)
 		}
@@ -396,6 +1028,12 @@ func (r *Reconciler) validateState(s, ns *state.State) error {
 			if key != state.ResourceKey(dep.GroupVersionKind()) {
 				return fmt.Errorf("dependents[%s][%d]: namespace does not match", key, i)
 			}
+			if clusterScoped && dep.GetNamespace() != "" {
+				return fmt.Errorf("dependents[%s][%d]: namespace must be empty for cluster-scoped resource", key, i)
+			}
+			if !clusterScoped && dep.GetNamespace() == "" {
+				return fmt.Errorf("dependents[%s][%d]: namespace must be specified", key, i)
+			}
 		}
 	}
 
@@ -467,6 +1105,192 @@ func getReferenceNames(res *unstructured.Unstructured, namePath string) ([]strin
 	return names, nil
 }
 
+// findDependent returns the dependent resource in the state that has
+// the same kind, namespace and name as specified resource.
+func findDependent(s *state.State, res *unstructured.Unstructured) *unstructured.Unstructured {
+	key := state.ResourceKey(res.GroupVersionKind())
+	for _, dep := range s.Dependents[key] {
+		if dep.GetNamespace() == res.GetNamespace() && dep.GetName() == res.GetName() {
+			return dep
+		}
+	}
+
+	return nil
+}
+
+// withoutStatus returns a copy of the resource without status field.
+func withoutStatus(res *unstructured.Unstructured) *unstructured.Unstructured {
+	r := res.DeepCopy()
+	unstructured.RemoveNestedField(r.Object, "status")
+	return r
+}
+
+// resetErrorCondition marks the condition set by the handler error as
+// succeeded if the object has it.
+func resetErrorCondition(res *unstructured.Unstructured) error {
+	cond, err := state.GetCondition(res, conditionReconciled)
+	if err != nil {
+		return err
+	}
+
+	if cond == nil || cond.Status != state.ConditionFalse {
+		return nil
+	}
+
+	return state.SetCondition(res, state.Condition{
+		Type:               conditionReconciled,
+		Status:             state.ConditionTrue,
+		Reason:             "Succeeded",
+		ObservedGeneration: res.GetGeneration(),
+	})
+}
+
+// mergeConditions merges the conditions reported by the handler into
+// the status of the object. The observed generation of the conditions
+// is set to the generation of the object.
+func mergeConditions(s *state.State) error {
+	for _, cond := range s.Conditions {
+		err := cond.Validate()
+		if err != nil {
+			log.Info("Ignored condition due to the condition is invalid", "namespace", s.Object.GetNamespace(), "name", s.Object.GetName(), "error", err.Error())
+			continue
+		}
+
+		cond.LastTransitionTime = ""
+		cond.ObservedGeneration = s.Object.GetGeneration()
+
+		err = state.SetCondition(s.Object, cond)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newRateLimiter returns a new rate limiter that combines the per-item
+// exponential backoff and the overall token bucket.
+func newRateLimiter(c *config.RateLimitConfig) (workqueue.RateLimiter, error) {
+	var err error
+
+	baseDelay := defaultBaseDelay
+	if c.BaseDelay != "" {
+		baseDelay, err = time.ParseDuration(c.BaseDelay)
+		if err != nil {
+			return nil, errors.New("invalid rate limit base delay")
+		}
+	}
+
+	maxDelay := defaultMaxDelay
+	if c.MaxDelay != "" {
+		maxDelay, err = time.ParseDuration(c.MaxDelay)
+		if err != nil {
+			return nil, errors.New("invalid rate limit max delay")
+		}
+	}
+
+	qps := defaultQPS
+	if c.QPS > 0 {
+		qps = c.QPS
+	}
+
+	burst := defaultBurst
+	if c.Burst > 0 {
+		burst = c.Burst
+	}
+
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	), nil
+}
+
+// getReferenceNamespace returns the namespace of reference resources
+// specified by the namespace field path. If the path is empty or the
+// field is missing, the namespace of the resource is returned.
+func getReferenceNamespace(res *unstructured.Unstructured, namespacePath string) (string, error) {
+	if namespacePath == "" {
+		return res.GetNamespace(), nil
+	}
+
+	namespaces, err := getReferenceNames(res, namespacePath)
+	if err != nil {
+		return "", err
+	}
+
+	if len(namespaces) > 1 {
+		return "", errors.New("namespace field path must point to a single value")
+	}
+
+	if len(namespaces) == 0 || namespaces[0] == "" {
+		return res.GetNamespace(), nil
+	}
+
+	return namespaces[0], nil
+}
+
+// getReferenceSelector returns the label selector specified by the
+// selector field path. If the field is missing, nil is returned.
+func getReferenceSelector(res *unstructured.Unstructured, selectorPath string) (labels.Selector, error) {
+	jp := jsonpath.New("selector")
+	jp.AllowMissingKeys(true)
+
+	err := jp.Parse(fmt.Sprintf("{%s}", selectorPath))
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := jp.FindResults(res.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	for x := range results {
+		for _, v := range results[x] {
+			values = append(values, v.Interface())
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	if len(values) > 1 {
+		return nil, errors.New("selector field path must point to a single value")
+	}
+
+	buf, err := json.Marshal(values[0])
+	if err != nil {
+		return nil, err
+	}
+
+	ls := metav1.LabelSelector{}
+	err = json.Unmarshal(buf, &ls)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	return metav1.LabelSelectorAsSelector(&ls)
+}
+
+// getReferenceName returns the namespaced name of reference resource.
+// The name can be in 'namespace/name' format to specify the namespace.
+// The namespace is required if the namespace of reference resources is
+// empty, such as for a cluster-scoped resource.
+func getReferenceName(namespace, name string) (types.NamespacedName, error) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 {
+		return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+	}
+
+	if namespace == "" {
+		return types.NamespacedName{}, fmt.Errorf("reference '%s' must be in 'namespace/name' format because the namespace is not specified", name)
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
 // isDeleting returns whether the specified resource is being deleted.
 func isDeleting(res *unstructured.Unstructured) bool {
 	_, ok, err := unstructured.NestedString(res.UnstructuredContent(), "metadata", "deletionTimestamp")
@@ -476,3 +1300,34 @@ func isDeleting(res *unstructured.Unstructured) bool {
 
 	return ok
 }
+
+// strategicMergePatch is a patch that computes strategic merge patch
+// from the original object and the modified object.
+type strategicMergePatch struct {
+	from *unstructured.Unstructured
+}
+
+// Type implements client.Patch interface.
+func (p *strategicMergePatch) Type() types.PatchType {
+	return types.StrategicMergePatchType
+}
+
+// Data implements client.Patch interface.
+func (p *strategicMergePatch) Data(obj runtime.Object) ([]byte, error) {
+	original, err := json.Marshal(p.from)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	dataStruct, err := scheme.Scheme.New(p.from.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+
+	return strategicpatch.CreateTwoWayMergePatch(original, modified, dataStruct)
+}
@@ -21,11 +21,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
 	"github.com/summerwind/whitebox-controller/reconciler/state"
 )
 
@@ -34,8 +36,13 @@ var kconfig *rest.Config
 func TestMain(m *testing.M) {
 	var err error
 
+	statusCRD := newCRD("StatusTest")
+	statusCRD.Spec.Subresources = &apiextensionsv1beta1.CustomResourceSubresources{
+		Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+	}
+
 	env := &envtest.Environment{
-		CRDs: []*apiextensionsv1beta1.CustomResourceDefinition{newCRD("Test")},
+		CRDs: []*apiextensionsv1beta1.CustomResourceDefinition{newCRD("Test"), statusCRD},
 	}
 
 	kconfig, err = env.Start()
@@ -186,6 +193,287 @@ func TestReconcile(t *testing.T) {
 	Expect(len(recorder.Events)).To(Equal(1))
 }
 
+func TestReconcileWithStatusSubresource(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	rc.Kind = "StatusTest"
+	rc.StatusSubresource = true
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	// Create target object
+	object := newObject(rc.GroupVersionKind, "test")
+	err = r.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer r.Delete(context.TODO(), object)
+
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	// Set reconcile handler
+	h.Func = func(s *state.State) error {
+		err := SetNestedField(s.Object.Object, "completed", "status", "phase")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = SetNestedField(s.Object.Object, "updated", "spec", "message")
+		Expect(err).NotTo(HaveOccurred())
+
+		return nil
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Test target object state
+	o := &Unstructured{}
+	o.SetGroupVersionKind(object.GroupVersionKind())
+	err = c.Get(context.TODO(), req.NamespacedName, o)
+	Expect(err).NotTo(HaveOccurred())
+
+	phase, ok, err := NestedString(o.Object, "status", "phase")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeTrue())
+	Expect(phase).To(Equal("completed"))
+
+	message, ok, err := NestedString(o.Object, "spec", "message")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeTrue())
+	Expect(message).To(Equal("updated"))
+}
+
+func TestReconcileWithObservedGeneration(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	rc.Kind = "StatusTest"
+	rc.StatusSubresource = true
+	rc.ObservedGeneration = true
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	// Create target object
+	object := newObject(rc.GroupVersionKind, "test")
+	err = r.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer r.Delete(context.TODO(), object)
+
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	// Set reconcile handler
+	h.Func = func(s *state.State) error {
+		return nil
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Test target object state
+	o := &Unstructured{}
+	o.SetGroupVersionKind(object.GroupVersionKind())
+	err = c.Get(context.TODO(), req.NamespacedName, o)
+	Expect(err).NotTo(HaveOccurred())
+
+	observed, ok, err := NestedInt64(o.Object, "status", "observedGeneration")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeTrue())
+	Expect(observed).To(Equal(o.GetGeneration()))
+
+	// Observed generation is not updated on handler error
+	err = SetNestedField(o.Object, "updated", "spec", "message")
+	Expect(err).NotTo(HaveOccurred())
+	err = c.Update(context.TODO(), o)
+	Expect(err).NotTo(HaveOccurred())
+
+	h.Func = func(s *state.State) error {
+		return errors.New("handler error")
+	}
+
+	_, err = r.Reconcile(req)
+	Expect(err).To(HaveOccurred())
+
+	err = c.Get(context.TODO(), req.NamespacedName, o)
+	Expect(err).NotTo(HaveOccurred())
+
+	observed, ok, err = NestedInt64(o.Object, "status", "observedGeneration")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeTrue())
+	Expect(observed).To(BeNumerically("<", o.GetGeneration()))
+}
+
+func TestReconcileWithUpdateStrategy(t *testing.T) {
+	RegisterTestingT(t)
+
+	strategies := []string{
+		config.UpdateStrategyUpdate,
+		config.UpdateStrategyMerge,
+		config.UpdateStrategyStrategicMerge,
+		config.UpdateStrategyApply,
+	}
+
+	for i, strategy := range strategies {
+		rc := newResourceConfig()
+		rc.Dependents[0].UpdateStrategy = strategy
+		recorder := record.NewFakeRecorder(32)
+		r, err := New(rc, recorder)
+		Expect(err).NotTo(HaveOccurred())
+
+		c := newClient()
+		r.InjectClient(c)
+
+		// Create target object
+		object := newObject(rc.GroupVersionKind, "test")
+		err = r.Create(context.TODO(), object)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Generate dependent object
+		ownerRef := metav1.NewControllerRef(object, object.GroupVersionKind())
+		p1 := newPod("p1")
+		p1.SetOwnerReferences([]metav1.OwnerReference{*ownerRef})
+		err = r.Create(context.TODO(), p1)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Enable test handler
+		h := &testHandler{}
+		r.handler = h
+
+		// Set reconcile handler
+		deadline := int64(120 + i)
+		h.Func = func(s *state.State) error {
+			Expect(len(s.Dependents["pod.v1"])).To(Equal(1))
+
+			p1 := s.Dependents["pod.v1"][0]
+			err := SetNestedField(p1.Object, deadline, "spec", "activeDeadlineSeconds")
+			Expect(err).NotTo(HaveOccurred())
+
+			return nil
+		}
+
+		// Run reconcile function
+		req := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: object.GetNamespace(),
+				Name:      object.GetName(),
+			},
+		}
+		_, err = r.Reconcile(req)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Test p1 object state
+		nn := types.NamespacedName{
+			Namespace: p1.GetNamespace(),
+			Name:      p1.GetName(),
+		}
+		pod := &corev1.Pod{}
+		err = c.Get(context.TODO(), nn, pod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*(pod.Spec.ActiveDeadlineSeconds)).To(Equal(deadline))
+
+		r.Delete(context.TODO(), p1)
+		r.Delete(context.TODO(), object)
+	}
+}
+
+func TestReconcileWithConflictRetry(t *testing.T) {
+	RegisterTestingT(t)
+
+	tests := []struct {
+		attempts  int
+		conflicts int
+		calls     int
+		err       bool
+		events    int
+	}{
+		{3, 1, 2, false, 0},
+		{2, 2, 2, true, 1},
+	}
+
+	for _, test := range tests {
+		rc := newResourceConfig()
+		rc.Reconciler.ConflictRetry = &config.RetryConfig{
+			Attempts: test.attempts,
+			Backoff:  "10ms",
+		}
+		recorder := record.NewFakeRecorder(32)
+		r, err := New(rc, recorder)
+		Expect(err).NotTo(HaveOccurred())
+
+		c := newClient()
+		r.InjectClient(c)
+
+		// Create target object
+		object := newObject(rc.GroupVersionKind, "test")
+		err = r.Create(context.TODO(), object)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Enable test handler
+		h := &testHandler{}
+		r.handler = h
+
+		// Set reconcile handler that causes conflicts
+		calls := 0
+		h.Func = func(s *state.State) error {
+			calls++
+
+			if calls <= test.conflicts {
+				o := s.Object.DeepCopy()
+				o.SetLabels(map[string]string{"call": fmt.Sprintf("%d", calls)})
+				err := c.Update(context.TODO(), o)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := SetNestedField(s.Object.Object, "completed", "status", "phase")
+			Expect(err).NotTo(HaveOccurred())
+
+			return nil
+		}
+
+		// Run reconcile function
+		req := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: object.GetNamespace(),
+				Name:      object.GetName(),
+			},
+		}
+		_, err = r.Reconcile(req)
+		if test.err {
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(calls).To(Equal(test.calls))
+		Expect(len(recorder.Events)).To(Equal(test.events))
+
+		r.Delete(context.TODO(), object)
+	}
+}
+
 func TestReconcileWithFinalizer(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -331,6 +619,60 @@ func TestReconcileWithNoObject(t *testing.T) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+func TestReconcileWithFilter(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
+	Expect(err).NotTo(HaveOccurred())
+
+	r.SetFilter(func(m metav1.Object) bool {
+		return m.GetLabels()["app"] == "test"
+	})
+
+	c := newClient()
+	r.InjectClient(c)
+
+	// Create target object without the label
+	object := newObject(rc.GroupVersionKind, "test")
+	err = r.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer r.Delete(context.TODO(), object)
+
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	called := 0
+	h.Func = func(s *state.State) error {
+		called++
+		return nil
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(called).To(Equal(0))
+
+	// Add the label to the object
+	err = c.Get(context.TODO(), req.NamespacedName, object)
+	Expect(err).NotTo(HaveOccurred())
+	object.SetLabels(map[string]string{"app": "test"})
+	err = c.Update(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(called).To(Equal(1))
+}
+
 func TestReconcileWithObjectDeletion(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -412,10 +754,15 @@ func TestReconcileWithHandlerError(t *testing.T) {
 	Expect(err).To(HaveOccurred())
 }
 
-func TestReconcileWithInvalidState(t *testing.T) {
+func TestReconcileWithRateLimit(t *testing.T) {
 	RegisterTestingT(t)
 
 	rc := newResourceConfig()
+	rc.Reconciler.RateLimit = &config.RateLimitConfig{
+		BaseDelay:  "1s",
+		MaxDelay:   "10s",
+		MaxRetries: 2,
+	}
 	recorder := record.NewFakeRecorder(32)
 	r, err := New(rc, recorder)
 	Expect(err).NotTo(HaveOccurred())
@@ -435,8 +782,7 @@ func TestReconcileWithInvalidState(t *testing.T) {
 
 	// Set reconcile handler
 	h.Func = func(s *state.State) error {
-		s.Object.SetName("invalid")
-		return nil
+		return errors.New("handler error")
 	}
 
 	// Run reconcile function
@@ -446,33 +792,254 @@ func TestReconcileWithInvalidState(t *testing.T) {
 			Name:      object.GetName(),
 		},
 	}
-	_, err = r.Reconcile(req)
-	Expect(err).To(HaveOccurred())
+
+	result, err := r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(1 * time.Second))
+
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(2 * time.Second))
+
+	// Drop the object after max retries
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(BeZero())
+	Expect(recorder.Events).To(Receive(ContainSubstring("RetriesExhausted")))
+
+	// Backoff is reset after dropping
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(1 * time.Second))
 }
 
-func TestObserve(t *testing.T) {
+func TestReconcileWithRateLimitAndRequeueAfter(t *testing.T) {
 	RegisterTestingT(t)
 
 	rc := newResourceConfig()
-	r, err := New(rc, nil)
+	rc.Reconciler.RateLimit = &config.RateLimitConfig{
+		BaseDelay:  "1s",
+		MaxDelay:   "10s",
+		MaxRetries: 2,
+	}
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
 	Expect(err).NotTo(HaveOccurred())
 
-	h := &testHandler{}
-	rc.Reconciler.HandlerConfig.StateHandler = h
-
 	c := newClient()
 	r.InjectClient(c)
 
+	// Create target object
 	object := newObject(rc.GroupVersionKind, "test")
-	err = c.Create(context.TODO(), object)
+	err = r.Create(context.TODO(), object)
 	Expect(err).NotTo(HaveOccurred())
-	defer c.Delete(context.TODO(), object)
+	defer r.Delete(context.TODO(), object)
 
-	tests := []struct {
-		name string
-		err  error
-	}{
-		{object.GetName(), nil},
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	// Set reconcile handler
+	h.Func = func(s *state.State) error {
+		return &handler.Error{
+			Reason:       "Unavailable",
+			RequeueAfter: 30,
+		}
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+
+	// The delay of the handler error is used
+	result, err := r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+
+	// The failures are counted and the object is dropped after max retries
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(BeZero())
+
+	// The events of the handler error are recorded before dropping
+	Eventually(recorder.Events).Should(Receive(ContainSubstring("RetriesExhausted")))
+
+	// The failure count is reset by a successful reconcile
+	h.Func = func(s *state.State) error {
+		return nil
+	}
+
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+
+	h.Func = func(s *state.State) error {
+		return errors.New("handler error")
+	}
+
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(1 * time.Second))
+}
+
+func TestReconcileWithStructuredHandlerError(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	// Create target object
+	object := newObject(rc.GroupVersionKind, "test")
+	err = r.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer r.Delete(context.TODO(), object)
+
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	retryable := false
+	h.Func = func(s *state.State) error {
+		return &handler.Error{
+			Reason:    "InvalidSpec",
+			Message:   "invalid spec",
+			Retryable: &retryable,
+		}
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+
+	// Non-retryable error
+	result, err := r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(BeZero())
+	Expect(recorder.Events).To(Receive(ContainSubstring("InvalidSpec")))
+
+	o := &Unstructured{}
+	o.SetGroupVersionKind(object.GroupVersionKind())
+	err = r.Get(context.TODO(), req.NamespacedName, o)
+	Expect(err).NotTo(HaveOccurred())
+
+	cond, err := state.GetCondition(o, "Reconciled")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond.Status).To(Equal(state.ConditionFalse))
+	Expect(cond.Reason).To(Equal("InvalidSpec"))
+
+	// Retryable error with requeue after
+	h.Func = func(s *state.State) error {
+		return &handler.Error{
+			Reason:       "Unavailable",
+			RequeueAfter: 30,
+		}
+	}
+
+	result, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+
+	// Retryable error
+	h.Func = func(s *state.State) error {
+		return &handler.Error{Reason: "Unavailable"}
+	}
+
+	_, err = r.Reconcile(req)
+	Expect(err).To(HaveOccurred())
+
+	// Reset the condition on success
+	h.Func = func(s *state.State) error {
+		return nil
+	}
+
+	_, err = r.Reconcile(req)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = r.Get(context.TODO(), req.NamespacedName, o)
+	Expect(err).NotTo(HaveOccurred())
+
+	cond, err = state.GetCondition(o, "Reconciled")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond.Status).To(Equal(state.ConditionTrue))
+}
+
+func TestReconcileWithInvalidState(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	recorder := record.NewFakeRecorder(32)
+	r, err := New(rc, recorder)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	// Create target object
+	object := newObject(rc.GroupVersionKind, "test")
+	err = r.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer r.Delete(context.TODO(), object)
+
+	// Enable test handler
+	h := &testHandler{}
+	r.handler = h
+
+	// Set reconcile handler
+	h.Func = func(s *state.State) error {
+		s.Object.SetName("invalid")
+		return nil
+	}
+
+	// Run reconcile function
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		},
+	}
+	_, err = r.Reconcile(req)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestObserve(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	r, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	h := &testHandler{}
+	rc.Reconciler.HandlerConfig.StateHandler = h
+
+	c := newClient()
+	r.InjectClient(c)
+
+	object := newObject(rc.GroupVersionKind, "test")
+	err = c.Create(context.TODO(), object)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), object)
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{object.GetName(), nil},
 		{"invalid", nil},
 		{object.GetName(), errors.New("error")},
 	}
@@ -523,6 +1090,100 @@ func TestGetDependents(t *testing.T) {
 	Expect(len(deps["pod.v1"])).To(Equal(1))
 }
 
+func TestGetDependentsWithCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	r, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	startCache(r, stop)
+
+	object := newObject(rc.GroupVersionKind, "test")
+	ownerRef := metav1.NewControllerRef(object, object.GroupVersionKind())
+
+	p1 := newPod("p1")
+	p1.SetOwnerReferences([]metav1.OwnerReference{*ownerRef})
+	err = c.Create(context.TODO(), p1)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), p1)
+
+	p2 := newPod("p2")
+	err = c.Create(context.TODO(), p2)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), p2)
+
+	Eventually(func() int {
+		deps, err := r.getDependents(object)
+		Expect(err).NotTo(HaveOccurred())
+		return len(deps["pod.v1"])
+	}).Should(Equal(1))
+}
+
+func BenchmarkGetDependents(b *testing.B) {
+	RegisterTestingT(b)
+
+	rc := newResourceConfig()
+	c := newClient()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	indexed, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+	indexed.InjectClient(c)
+	ch := startCache(indexed, stop)
+
+	// The reconciler without index reads from the same cache.
+	filtered, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+	filtered.InjectClient(&client.DelegatingClient{Reader: ch, Writer: c, StatusClient: c})
+
+	object := newObject(rc.GroupVersionKind, "test")
+	owners := []*Unstructured{object}
+	for i := 0; i < 9; i++ {
+		owners = append(owners, newObject(rc.GroupVersionKind, fmt.Sprintf("test-%d", i)))
+	}
+
+	for i := 0; i < 1000; i++ {
+		owner := owners[i%len(owners)]
+		p := newPod(fmt.Sprintf("bench-%d", i))
+		p.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(owner, owner.GroupVersionKind())})
+		err = c.Create(context.TODO(), p)
+		Expect(err).NotTo(HaveOccurred())
+		defer c.Delete(context.TODO(), p)
+	}
+
+	Eventually(func() int {
+		deps, err := indexed.getDependents(object)
+		Expect(err).NotTo(HaveOccurred())
+		return len(deps["pod.v1"])
+	}, 30*time.Second).Should(Equal(100))
+
+	b.Run("Filter", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := filtered.getDependents(object)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := indexed.getDependents(object)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func TestGetReferences(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -565,6 +1226,108 @@ func TestGetReferences(t *testing.T) {
 	}
 }
 
+func TestGetReferencesWithNamespace(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	rc.References[0].AllowedNamespaces = []string{"kube-public"}
+	r, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	object := newObject(rc.GroupVersionKind, "test")
+	SetNestedStringSlice(object.Object, []string{"kube-public/c1", "kube-system/c1"}, "spec", "configMapRefs")
+	SetNestedField(object.Object, "kube-public", "spec", "namespace")
+
+	c1 := newConfigMap("c1")
+	c1.SetNamespace("kube-public")
+	err = c.Create(context.TODO(), c1)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), c1)
+
+	c2 := newConfigMap("c1")
+	c2.SetNamespace("kube-system")
+	err = c.Create(context.TODO(), c2)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), c2)
+
+	tests := []struct {
+		nameFieldPath      string
+		namespaceFieldPath string
+		length             int
+	}{
+		{".spec.configMapRefs[0]", "", 1},
+		{".spec.configMapRefs[1]", "", 0},
+		{".spec.configMapRefs[*]", "", 1},
+		{".metadata.name", ".spec.namespace", 0},
+	}
+
+	for _, test := range tests {
+		rc.References[0].NameFieldPath = test.nameFieldPath
+		rc.References[0].NamespaceFieldPath = test.namespaceFieldPath
+
+		refs, err := r.getReferences(object)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(refs["configmap.v1"])).To(Equal(test.length))
+
+		for _, ref := range refs["configmap.v1"] {
+			Expect(ref.GetNamespace()).To(Equal("kube-public"))
+		}
+	}
+}
+
+func TestGetReferencesWithSelector(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	rc.References[0].NameFieldPath = ""
+	r, err := New(rc, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	c := newClient()
+	r.InjectClient(c)
+
+	object := newObject(rc.GroupVersionKind, "test")
+	SetNestedStringMap(object.Object, map[string]string{"app": "selector-test"}, "spec", "selector", "matchLabels")
+	SetNestedField(object.Object, "invalid", "spec", "invalidSelector")
+
+	c1 := newConfigMap("selector-c1")
+	c1.SetLabels(map[string]string{"app": "selector-test"})
+	err = c.Create(context.TODO(), c1)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), c1)
+
+	c2 := newConfigMap("selector-c2")
+	c2.SetLabels(map[string]string{"app": "other"})
+	err = c.Create(context.TODO(), c2)
+	Expect(err).NotTo(HaveOccurred())
+	defer c.Delete(context.TODO(), c2)
+
+	tests := []struct {
+		selectorFieldPath string
+		length            int
+		err               bool
+	}{
+		{".spec.selector", 1, false},
+		{".spec.missingSelector", 0, false},
+		{".spec.invalidSelector", 0, true},
+	}
+
+	for _, test := range tests {
+		rc.References[0].SelectorFieldPath = test.selectorFieldPath
+
+		refs, err := r.getReferences(object)
+		if test.err {
+			Expect(err).To(HaveOccurred())
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(refs["configmap.v1"])).To(Equal(test.length))
+		}
+	}
+}
+
 func TestSetFinalizer(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -657,6 +1420,18 @@ func TestValidateState(t *testing.T) {
 	s7.Dependents["pod.v1"][0].SetKind("Invalid")
 	err = r.validateState(s, s7)
 	Expect(err).To(HaveOccurred())
+
+	// Invalid state with namespaced dependent without namespace
+	s8 := s.Copy()
+	s8.Dependents["pod.v1"][0].SetNamespace("")
+	err = r.validateState(s, s8)
+	Expect(err).To(HaveOccurred())
+
+	// Invalid state with cluster-scoped dependent with namespace
+	rc.Dependents[0].Scope = config.ScopeCluster
+	s9 := s.Copy()
+	err = r.validateState(s, s9)
+	Expect(err).To(HaveOccurred())
 }
 
 func TestSetOwnerReference(t *testing.T) {
@@ -708,13 +1483,198 @@ func TestGetReferenceNames(t *testing.T) {
 	Expect(len(refs)).To(Equal(0))
 }
 
+func TestGetReferenceName(t *testing.T) {
+	RegisterTestingT(t)
+
+	nn, err := getReferenceName("default", "test")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nn.Namespace).To(Equal("default"))
+	Expect(nn.Name).To(Equal("test"))
+
+	nn, err = getReferenceName("default", "shared/test")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nn.Namespace).To(Equal("shared"))
+	Expect(nn.Name).To(Equal("test"))
+
+	// Reference from a cluster-scoped resource
+	nn, err = getReferenceName("", "shared/test")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nn.Namespace).To(Equal("shared"))
+	Expect(nn.Name).To(Equal("test"))
+
+	_, err = getReferenceName("", "test")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestMergeConditions(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	s := newState(rc)
+	s.Object.SetGeneration(2)
+
+	err := state.SetCondition(s.Object, state.Condition{
+		Type:               "Ready",
+		Status:             state.ConditionFalse,
+		LastTransitionTime: "2019-01-01T00:00:00Z",
+		ObservedGeneration: 1,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	s.Conditions = []state.Condition{
+		{Type: "Ready", Status: state.ConditionFalse, Reason: "Progressing"},
+		{Type: "Degraded", Status: state.ConditionFalse},
+		{Type: "Invalid", Status: "Invalid"},
+	}
+
+	err = mergeConditions(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	cond, err := state.GetCondition(s.Object, "Ready")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond.Reason).To(Equal("Progressing"))
+	Expect(cond.LastTransitionTime).To(Equal("2019-01-01T00:00:00Z"))
+	Expect(cond.ObservedGeneration).To(Equal(int64(2)))
+
+	cond, err = state.GetCondition(s.Object, "Degraded")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond).NotTo(BeNil())
+	Expect(cond.LastTransitionTime).NotTo(BeEmpty())
+
+	cond, err = state.GetCondition(s.Object, "Invalid")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond).To(BeNil())
+
+	// Merging the unchanged condition does not change the object decoded
+	// from JSON.
+	buf, err := s.Object.MarshalJSON()
+	Expect(err).NotTo(HaveOccurred())
+
+	decoded := &Unstructured{}
+	err = decoded.UnmarshalJSON(buf)
+	Expect(err).NotTo(HaveOccurred())
+
+	s = &state.State{
+		Object:     decoded,
+		Conditions: []state.Condition{{Type: "Ready", Status: state.ConditionFalse, Reason: "Progressing"}},
+	}
+	expected := decoded.DeepCopy()
+
+	err = mergeConditions(s)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(s.Object).To(Equal(expected))
+}
+
+func TestGetReferenceSelector(t *testing.T) {
+	RegisterTestingT(t)
+
+	object := newObject(schema.GroupVersionKind{Group: "example.com", Version: "v1alpha1", Kind: "Test"}, "test")
+	SetNestedStringMap(object.Object, map[string]string{"app": "test"}, "spec", "selector", "matchLabels")
+
+	selector, err := getReferenceSelector(object, ".spec.selector")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(selector.String()).To(Equal("app=test"))
+
+	selector, err = getReferenceSelector(object, ".spec.missing")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(selector).To(BeNil())
+
+	_, err = getReferenceSelector(object, ".spec.selector.matchLabels.app")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestReferenceKeys(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	ref := rc.References[0]
+	ref.NameFieldPath = ".spec.configMapRefs[*]"
+	ref.AllowedNamespaces = []string{"shared"}
+
+	object := newObject(rc.GroupVersionKind, "test")
+	SetNestedStringSlice(object.Object, []string{"c1", "shared/c2", "kube-system/c3"}, "spec", "configMapRefs")
+
+	keys, err := ReferenceKeys(object, ref)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(keys).To(ConsistOf("default/c1", "shared/c2"))
+
+	ref.NameFieldPath = ""
+	keys, err = ReferenceKeys(object, ref)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(keys).To(BeEmpty())
+}
+
+func TestSelectsReference(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	ref := rc.References[0]
+	ref.NameFieldPath = ""
+	ref.SelectorFieldPath = ".spec.selector"
+
+	object := newObject(rc.GroupVersionKind, "test")
+	SetNestedStringMap(object.Object, map[string]string{"app": "test"}, "spec", "selector", "matchLabels")
+
+	cm := newConfigMap("c1")
+	cm.SetLabels(map[string]string{"app": "test"})
+
+	ok, err := SelectsReference(object, ref, cm)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeTrue())
+
+	cm.SetLabels(map[string]string{"app": "other"})
+	ok, err = SelectsReference(object, ref, cm)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeFalse())
+
+	cm.SetLabels(map[string]string{"app": "test"})
+	cm.SetNamespace("kube-system")
+	ok, err = SelectsReference(object, ref, cm)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ok).To(BeFalse())
+}
+
+func TestNewApplyObject(t *testing.T) {
+	RegisterTestingT(t)
+
+	rc := newResourceConfig()
+	object := newObject(rc.GroupVersionKind, "test")
+	object.SetUID(uuid.NewUUID())
+	object.SetResourceVersion("1")
+	object.SetGeneration(1)
+	object.SetCreationTimestamp(metav1.Now())
+	object.SetLabels(map[string]string{"app": "test"})
+	SetNestedField(object.Object, "hello", "spec", "message")
+	SetNestedField(object.Object, "completed", "status", "phase")
+	SetNestedSlice(object.Object, []interface{}{
+		map[string]interface{}{"manager": "other"},
+	}, "metadata", "managedFields")
+
+	obj := newApplyObject(object)
+	Expect(obj.Object).To(Equal(map[string]interface{}{
+		"apiVersion": object.GetAPIVersion(),
+		"kind":       object.GetKind(),
+		"metadata": map[string]interface{}{
+			"namespace": object.GetNamespace(),
+			"name":      object.GetName(),
+			"labels":    map[string]interface{}{"app": "test"},
+		},
+		"spec": map[string]interface{}{
+			"message": "hello",
+		},
+	}))
+
+	// The original resource is not modified.
+	Expect(object.GetResourceVersion()).To(Equal("1"))
+}
+
 func TestIsDeleting(t *testing.T) {
 	RegisterTestingT(t)
 
 	rc := newResourceConfig()
 	object := newObject(rc.GroupVersionKind, "test")
 	deleting := newObject(rc.GroupVersionKind, "test")
-	SetNestedField(deleting.Object, string(time.Now().Unix()), "metadata", "deletionTimestamp")
+	SetNestedField(deleting.Object, time.Now().Format(time.RFC3339), "metadata", "deletionTimestamp")
 
 	Expect(isDeleting(object)).To(BeFalse())
 	Expect(isDeleting(deleting)).To(BeTrue())
@@ -738,6 +1698,19 @@ func newClient() client.Client {
 	return cl
 }
 
+func startCache(r *Reconciler, stop chan struct{}) cache.Cache {
+	ch, err := cache.New(kconfig, cache.Options{})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = r.InjectCache(ch)
+	Expect(err).NotTo(HaveOccurred())
+
+	go ch.Start(stop)
+	Expect(ch.WaitForCacheSync(stop)).To(BeTrue())
+
+	return ch
+}
+
 func newResourceConfig() *config.ResourceConfig {
 	return &config.ResourceConfig{
 		GroupVersionKind: schema.GroupVersionKind{
@@ -0,0 +1,139 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Condition statuses.
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// Condition represents a condition in the status of resource.
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// Validate validates the content of condition.
+func (c *Condition) Validate() error {
+	if c.Type == "" {
+		return errors.New("type must be specified")
+	}
+
+	switch c.Status {
+	case ConditionTrue, ConditionFalse, ConditionUnknown:
+	default:
+		return fmt.Errorf("invalid status: %s", c.Status)
+	}
+
+	return nil
+}
+
+// GetCondition returns the condition of specified type in the status
+// of the object. If the object does not have the condition, nil is
+// returned.
+func GetCondition(obj *unstructured.Unstructured, condType string) (*Condition, error) {
+	conds, err := getConditions(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range conds {
+		if conds[i].Type == condType {
+			return &conds[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SetCondition sets the condition to the status of the object. If the
+// object already has the condition of the same type, the condition is
+// replaced. The last transition time is updated only when the status
+// of the condition is changed.
+func SetCondition(obj *unstructured.Unstructured, c Condition) error {
+	conds, err := getConditions(obj)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range conds {
+		if conds[i].Type != c.Type {
+			continue
+		}
+
+		if conds[i].Status == c.Status && c.LastTransitionTime == "" {
+			c.LastTransitionTime = conds[i].LastTransitionTime
+		}
+		conds[i] = c
+		found = true
+	}
+
+	if !found {
+		conds = append(conds, c)
+	}
+
+	for i := range conds {
+		if conds[i].LastTransitionTime == "" {
+			conds[i].LastTransitionTime = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+
+	return setConditions(obj, conds)
+}
+
+// getConditions returns the conditions in the status of the object.
+func getConditions(obj *unstructured.Unstructured) ([]Condition, error) {
+	conds := []Condition{}
+
+	val, found, err := unstructured.NestedFieldNoCopy(obj.Object, "status", "conditions")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found || val == nil {
+		return conds, nil
+	}
+
+	buf, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(buf, &conds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conditions: %v", err)
+	}
+
+	return conds, nil
+}
+
+// setConditions sets the conditions to the status of the object. The
+// conditions are converted without JSON so that the numbers are kept as
+// int64 like the objects decoded by the client.
+func setConditions(obj *unstructured.Unstructured, conds []Condition) error {
+	val := make([]interface{}, 0, len(conds))
+	for i := range conds {
+		c, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conds[i])
+		if err != nil {
+			return err
+		}
+		val = append(val, c)
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, val, "status", "conditions")
+}
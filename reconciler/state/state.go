@@ -16,6 +16,7 @@ type State struct {
 	Dependents   map[string][]*unstructured.Unstructured `json:"dependents,omitempty"`
 	References   map[string][]*unstructured.Unstructured `json:"references,omitempty"`
 	Events       []Event                                 `json:"events,omitempty"`
+	Conditions   []Condition                             `json:"conditions,omitempty"`
 	Requeue      bool                                    `json:"requeue,omitempty"`
 	RequeueAfter int                                     `json:"requeueAfter,omitempty"`
 }
@@ -63,6 +64,13 @@ func (s *State) Copy() *State {
 		}
 	}
 
+	if len(s.Conditions) > 0 {
+		ns.Conditions = make([]Condition, len(s.Conditions))
+		for i := range s.Conditions {
+			ns.Conditions[i] = s.Conditions[i]
+		}
+	}
+
 	return ns
 }
 
@@ -112,7 +120,7 @@ func (s *State) Diff(ns *State) ([]*unstructured.Unstructured, []*unstructured.U
 				if dep.GetName() != newDep.GetName() {
 					continue
 				}
-				if s.Object.GetNamespace() != newDep.GetNamespace() {
+				if !isOwnable(s.Object, newDep) {
 					continue
 				}
 
@@ -149,7 +157,7 @@ func (s *State) Diff(ns *State) ([]*unstructured.Unstructured, []*unstructured.U
 				continue
 			}
 
-			if s.Object.GetNamespace() != newDep.GetNamespace() {
+			if !isOwnable(s.Object, newDep) {
 				continue
 			}
 			if key != ResourceKey(newDep.GroupVersionKind()) {
@@ -193,6 +201,12 @@ func Unpack(state *State, v interface{}) error {
 	return nil
 }
 
+// isOwnable returns whether the dependent resource can be owned by
+// the object. A cluster-scoped object can own resources in any namespace.
+func isOwnable(object, dep *unstructured.Unstructured) bool {
+	return object.GetNamespace() == "" || object.GetNamespace() == dep.GetNamespace()
+}
+
 func ResourceKey(gvk schema.GroupVersionKind) string {
 	if gvk.Group == "" {
 		return strings.ToLower(fmt.Sprintf("%s.%s", gvk.Kind, gvk.Version))
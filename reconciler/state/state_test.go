@@ -90,6 +90,112 @@ func TestDiff(t *testing.T) {
 	Expect([]string{deleted[0].GetName(), deleted[1].GetName()}).To(ConsistOf("a2", "b2"))
 }
 
+func TestDiffWithClusterScopedObject(t *testing.T) {
+	RegisterTestingT(t)
+
+	object := newObject("Resource", "test")
+	object.SetNamespace("")
+
+	s := &State{
+		Object: object,
+		Dependents: map[string][]*Unstructured{
+			"a.v1alpha1.example.com": []*Unstructured{},
+		},
+	}
+
+	ns := s.Copy()
+
+	a1 := newObject("A", "a1")
+	a1.SetNamespace("ns1")
+	a2 := newObject("A", "a2")
+	a2.SetNamespace("")
+	ns.Dependents["a.v1alpha1.example.com"] = []*Unstructured{a1, a2}
+
+	created, updated, deleted := s.Diff(ns)
+
+	Expect(len(created)).To(Equal(2))
+	Expect([]string{created[0].GetName(), created[1].GetName()}).To(ConsistOf("a1", "a2"))
+	Expect(len(updated)).To(Equal(0))
+	Expect(len(deleted)).To(Equal(0))
+
+	// Namespaced object cannot own resources in other namespaces.
+	s.Object.SetNamespace("default")
+	ns.Object.SetNamespace("default")
+
+	created, _, _ = s.Diff(ns)
+	Expect(len(created)).To(Equal(0))
+}
+
+func TestSetCondition(t *testing.T) {
+	RegisterTestingT(t)
+
+	object := newObject("Resource", "test")
+
+	cond, err := GetCondition(object, "Ready")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond).To(BeNil())
+
+	err = SetCondition(object, Condition{Type: "Ready", Status: ConditionFalse, Reason: "Failed", LastTransitionTime: "2019-01-01T00:00:00Z"})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = SetCondition(object, Condition{Type: "Synced", Status: ConditionTrue})
+	Expect(err).NotTo(HaveOccurred())
+
+	// Keep last transition time if the status is not changed
+	err = SetCondition(object, Condition{Type: "Ready", Status: ConditionFalse, Reason: "StillFailed"})
+	Expect(err).NotTo(HaveOccurred())
+
+	cond, err = GetCondition(object, "Ready")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond.Reason).To(Equal("StillFailed"))
+	Expect(cond.LastTransitionTime).To(Equal("2019-01-01T00:00:00Z"))
+
+	// Update last transition time if the status is changed
+	err = SetCondition(object, Condition{Type: "Ready", Status: ConditionTrue})
+	Expect(err).NotTo(HaveOccurred())
+
+	cond, err = GetCondition(object, "Ready")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cond.Status).To(Equal(ConditionTrue))
+	Expect(cond.LastTransitionTime).NotTo(Equal("2019-01-01T00:00:00Z"))
+
+	conds, _, err := NestedSlice(object.Object, "status", "conditions")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(len(conds)).To(Equal(2))
+
+	// Setting the unchanged condition does not change the object decoded
+	// from JSON, which has the numbers as int64.
+	err = SetCondition(object, Condition{Type: "Synced", Status: ConditionTrue, ObservedGeneration: 1})
+	Expect(err).NotTo(HaveOccurred())
+
+	buf, err := object.MarshalJSON()
+	Expect(err).NotTo(HaveOccurred())
+
+	decoded := &unstructured.Unstructured{}
+	err = decoded.UnmarshalJSON(buf)
+	Expect(err).NotTo(HaveOccurred())
+	expected := decoded.DeepCopy()
+
+	cond, err = GetCondition(decoded, "Synced")
+	Expect(err).NotTo(HaveOccurred())
+	err = SetCondition(decoded, *cond)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(decoded).To(Equal(expected))
+}
+
+func TestConditionValidate(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := Condition{Type: "Ready", Status: ConditionTrue}
+	Expect(c.Validate()).To(Succeed())
+
+	c = Condition{Status: ConditionTrue}
+	Expect(c.Validate()).NotTo(Succeed())
+
+	c = Condition{Type: "Ready", Status: "Invalid"}
+	Expect(c.Validate()).NotTo(Succeed())
+}
+
 func TestPack(t *testing.T) {
 	RegisterTestingT(t)
 
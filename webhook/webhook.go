@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,7 +21,9 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/summerwind/whitebox-controller/config"
+	"github.com/summerwind/whitebox-controller/handler"
 	"github.com/summerwind/whitebox-controller/handler/common"
+	"github.com/summerwind/whitebox-controller/metrics"
 	"github.com/summerwind/whitebox-controller/webhook/injection"
 )
 
@@ -31,9 +34,11 @@ var (
 
 type Server struct {
 	client.Client
-	config  *config.ServerConfig
-	mux     *http.ServeMux
-	handler http.Handler
+	mgr       manager.Manager
+	config    *config.ServerConfig
+	mux       *http.ServeMux
+	handler   http.Handler
+	listening int32
 }
 
 func NewServer(c *config.ServerConfig, mgr manager.Manager) (*Server, error) {
@@ -47,6 +52,7 @@ func NewServer(c *config.ServerConfig, mgr manager.Manager) (*Server, error) {
 	}
 
 	s := &Server{
+		mgr:     mgr,
 		config:  c,
 		mux:     mux,
 		handler: wrap(mux),
@@ -76,6 +82,9 @@ func (s *Server) Start(stop <-chan struct{}) error {
 		return err
 	}
 
+	atomic.StoreInt32(&s.listening, 1)
+	defer atomic.StoreInt32(&s.listening, 0)
+
 	server := &http.Server{
 		Handler: s.handler,
 	}
@@ -105,12 +114,37 @@ func (s *Server) Start(stop <-chan struct{}) error {
 	return nil
 }
 
+// NeedLeaderElection implements manager.LeaderElectionRunnable interface.
+// The webhook server is run on all replicas regardless of leader election.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Ready returns an error until the server starts listening for requests.
+func (s *Server) Ready(req *http.Request) error {
+	if atomic.LoadInt32(&s.listening) == 0 {
+		return errors.New("webhook server is not listening")
+	}
+
+	return nil
+}
+
 func (s *Server) AddValidator(c *config.ResourceConfig) error {
-	hook, err := newValidationHook(c.Validator)
+	h, err := common.NewAdmissionRequestHandler(c.Validator, metrics.Labels{
+		Controller: getControllerName(c.GroupVersionKind),
+		Handler:    metrics.HandlerValidator,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = common.AddRunnable(s.mgr, h)
 	if err != nil {
 		return err
 	}
 
+	hook := newValidationHook(h)
+
 	p := fmt.Sprintf("%s/validate", getBasePath(c.GroupVersionKind))
 	log.Info("Adding validation hook", "path", p)
 	s.mux.Handle(p, hook)
@@ -119,11 +153,21 @@ func (s *Server) AddValidator(c *config.ResourceConfig) error {
 }
 
 func (s *Server) AddMutator(c *config.ResourceConfig) error {
-	hook, err := newMutationHook(c.Mutator)
+	h, err := common.NewAdmissionRequestHandler(c.Mutator, metrics.Labels{
+		Controller: getControllerName(c.GroupVersionKind),
+		Handler:    metrics.HandlerMutator,
+	})
 	if err != nil {
 		return err
 	}
 
+	err = common.AddRunnable(s.mgr, h)
+	if err != nil {
+		return err
+	}
+
+	hook := newMutationHook(h)
+
 	p := fmt.Sprintf("%s/mutate", getBasePath(c.GroupVersionKind))
 	log.Info("Adding mutation hook", "path", p)
 	s.mux.Handle(p, hook)
@@ -132,7 +176,20 @@ func (s *Server) AddMutator(c *config.ResourceConfig) error {
 }
 
 func (s *Server) AddInjector(c *config.ResourceConfig) error {
-	hook, err := newInjectionHook(c.Injector, s.Client)
+	h, err := common.NewInjectionRequestHandler(&c.Injector.HandlerConfig, metrics.Labels{
+		Controller: getControllerName(c.GroupVersionKind),
+		Handler:    metrics.HandlerInjector,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = common.AddRunnable(s.mgr, h)
+	if err != nil {
+		return err
+	}
+
+	hook, err := newInjectionHook(c.Injector, h, s.Client)
 	if err != nil {
 		return err
 	}
@@ -171,12 +228,11 @@ func getBasePath(gvk schema.GroupVersionKind) string {
 	}
 }
 
-func newValidationHook(hc *config.HandlerConfig) (http.Handler, error) {
-	h, err := common.NewAdmissionRequestHandler(hc)
-	if err != nil {
-		return nil, err
-	}
+func getControllerName(gvk schema.GroupVersionKind) string {
+	return fmt.Sprintf("%s-controller", strings.ToLower(gvk.Kind))
+}
 
+func newValidationHook(h handler.AdmissionRequestHandler) http.Handler {
 	validator := func(ctx context.Context, req admission.Request) admission.Response {
 		res, err := h.HandleAdmissionRequest(req)
 		if err != nil {
@@ -189,15 +245,10 @@ func newValidationHook(hc *config.HandlerConfig) (http.Handler, error) {
 	hook := &admission.Webhook{Handler: admission.HandlerFunc(validator)}
 	hook.InjectLogger(log)
 
-	return hook, nil
+	return hook
 }
 
-func newMutationHook(hc *config.HandlerConfig) (http.Handler, error) {
-	h, err := common.NewAdmissionRequestHandler(hc)
-	if err != nil {
-		return nil, err
-	}
-
+func newMutationHook(h handler.AdmissionRequestHandler) http.Handler {
 	mutator := func(ctx context.Context, req admission.Request) admission.Response {
 		res, err := h.HandleAdmissionRequest(req)
 		if err != nil {
@@ -210,10 +261,10 @@ func newMutationHook(hc *config.HandlerConfig) (http.Handler, error) {
 	hook := &admission.Webhook{Handler: admission.HandlerFunc(mutator)}
 	hook.InjectLogger(log)
 
-	return hook, nil
+	return hook
 }
 
-func newInjectionHook(ic *config.InjectorConfig, client client.Client) (http.Handler, error) {
+func newInjectionHook(ic *config.InjectorConfig, h handler.InjectionRequestHandler, client client.Client) (http.Handler, error) {
 	var (
 		key interface{}
 		err error
@@ -243,12 +294,7 @@ func newInjectionHook(ic *config.InjectorConfig, client client.Client) (http.Han
 		return nil, errors.New("unsupported signing key type")
 	}
 
-	h, err := common.NewInjectionRequestHandler(&ic.HandlerConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	handler := func(ctx context.Context, req injection.Request) (injection.Response, error) {
+	injector := func(ctx context.Context, req injection.Request) (injection.Response, error) {
 		res, err := h.HandleInjectionRequest(req)
 		if err != nil {
 			return res, errors.New("Handler error")
@@ -258,7 +304,7 @@ func newInjectionHook(ic *config.InjectorConfig, client client.Client) (http.Han
 	}
 
 	hook := &injection.Webhook{
-		Handler:    injection.HandlerFunc(handler),
+		Handler:    injection.HandlerFunc(injector),
 		KeyHandler: keyHandler,
 	}
 	hook.InjectClient(client)